@@ -0,0 +1,338 @@
+package client
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+const (
+	// AuthBearer selects the BearerAuth authenticator in AuthConf.
+	AuthBearer = "bearer"
+	// AuthBasic selects the BasicAuth authenticator in AuthConf.
+	AuthBasic = "basic"
+	// AuthAPIKey selects the APIKeyAuth authenticator in AuthConf.
+	AuthAPIKey = "api_key"
+
+	// APIKeyInHeader sends the API key in a request header.
+	APIKeyInHeader = "header"
+	// APIKeyInQuery sends the API key as a query parameter.
+	APIKeyInQuery = "query"
+
+	// redacted replaces the credentials in URLs and printed configurations.
+	redacted = "REDACTED"
+)
+
+var (
+	// sensitiveQueryParams are the query parameters always redacted from the URLs
+	// reported in errors.
+	sensitiveQueryParams = []string{
+		"access_token", "api_key", "apikey", "client_secret", "key",
+		"password", "secret", "sig", "signature", "token",
+	}
+)
+
+// Authenticator decorates the outgoing requests of a Connector with credentials.
+// Authenticate is called before each attempt of a request, on a copy of the
+// request built by the caller.
+type Authenticator interface {
+	Authenticate(req *http.Request) error
+}
+
+// queryRedactor is implemented by the authenticators sending credentials as
+// query parameters, so they can be redacted from the reported URLs.
+type queryRedactor interface {
+	redactedQueryParams() []string
+}
+
+// AuthConf selects and configures the Authenticator of a Connector.
+type AuthConf struct {
+	Type     string `yaml:"type"`      // One of "bearer", "basic", "api_key" or "oauth2"
+	Token    string `yaml:"token"`     // Token of the bearer authentication
+	Username string `yaml:"username"`  // Username of the basic authentication
+	Password string `yaml:"password"`  // Password of the basic authentication
+	KeyName  string `yaml:"key_name"`  // Header or query parameter name of the API key
+	KeyValue string `yaml:"key_value"` // Value of the API key
+	KeyIn    string `yaml:"key_in"`    // One of "header" or "query", default "header"
+
+	TokenURL     string   `yaml:"token_url"`     // Token endpoint of the OAuth2 authorization server
+	ClientID     string   `yaml:"client_id"`     // OAuth2 client identifier
+	ClientSecret string   `yaml:"client_secret"` // OAuth2 client secret
+	Scopes       []string `yaml:"scopes"`        // Optional OAuth2 requested scopes
+	AuthStyle    string   `yaml:"auth_style"`    // How the OAuth2 client credentials are sent, "header" or "body"
+}
+
+// String returns the configuration without its credentials.
+func (conf AuthConf) String() string {
+	return fmt.Sprintf("{Type:%s Username:%s KeyName:%s KeyIn:%s TokenURL:%s ClientID:%s Scopes:%v}",
+		conf.Type, conf.Username, conf.KeyName, conf.KeyIn, conf.TokenURL, conf.ClientID, conf.Scopes)
+}
+
+// validate checks the consistency of the authentication configuration.
+func (conf *AuthConf) validate() []error {
+	if conf == nil {
+		return nil
+	}
+
+	var errs []error
+	switch conf.Type {
+	case AuthBearer:
+		if conf.Token == "" {
+			errs = append(errs, &ConfError{Field: "token", Reason: "is required by bearer authentication"})
+		}
+
+	case AuthBasic:
+		if conf.Username == "" {
+			errs = append(errs, &ConfError{Field: "username", Reason: "is required by basic authentication"})
+		}
+
+	case AuthAPIKey:
+		if conf.KeyName == "" {
+			errs = append(errs, &ConfError{Field: "key_name", Reason: "is required by API key authentication"})
+		}
+		if conf.KeyValue == "" {
+			errs = append(errs, &ConfError{Field: "key_value", Reason: "is required by API key authentication"})
+		}
+		if conf.KeyIn != "" && conf.KeyIn != APIKeyInHeader && conf.KeyIn != APIKeyInQuery {
+			errs = append(errs, &ConfError{
+				Field:  "key_in",
+				Reason: fmt.Sprintf("unknown location %q, expected %s or %s", conf.KeyIn, APIKeyInHeader, APIKeyInQuery),
+			})
+		}
+
+	case AuthOAuth2:
+		errs = append(errs, validateBaseURL("token_url", conf.TokenURL)...)
+		if conf.ClientID == "" {
+			errs = append(errs, &ConfError{Field: "client_id", Reason: "is required by OAuth2 authentication"})
+		}
+		if conf.AuthStyle != "" && conf.AuthStyle != OAuth2AuthStyleHeader && conf.AuthStyle != OAuth2AuthStyleBody {
+			errs = append(errs, &ConfError{
+				Field:  "auth_style",
+				Reason: fmt.Sprintf("unknown style %q, expected %s or %s", conf.AuthStyle, OAuth2AuthStyleHeader, OAuth2AuthStyleBody),
+			})
+		}
+
+	default:
+		errs = append(errs, &ConfError{
+			Field: "type",
+			Reason: fmt.Sprintf("unknown type %q, expected %s, %s, %s or %s",
+				conf.Type, AuthBearer, AuthBasic, AuthAPIKey, AuthOAuth2),
+		})
+	}
+
+	return errs
+}
+
+// newAuthenticator returns the Authenticator configured by conf, nil if conf
+// is nil or invalid. The client is used to request OAuth2 tokens.
+func newAuthenticator(conf *AuthConf, client *http.Client) Authenticator {
+	if conf == nil {
+		return nil
+	}
+
+	switch conf.Type {
+	case AuthBearer:
+		return &BearerAuth{Token: conf.Token}
+	case AuthBasic:
+		return &BasicAuth{Username: conf.Username, Password: conf.Password}
+	case AuthAPIKey:
+		return &APIKeyAuth{Name: conf.KeyName, Value: conf.KeyValue, In: conf.KeyIn}
+	case AuthOAuth2:
+		return &OAuth2ClientCredentials{
+			TokenURL:     conf.TokenURL,
+			ClientID:     conf.ClientID,
+			ClientSecret: conf.ClientSecret,
+			Scopes:       conf.Scopes,
+			AuthStyle:    conf.AuthStyle,
+			Client:       client,
+		}
+	}
+
+	return nil
+}
+
+// BearerAuth sends a static token in the Authorization header.
+type BearerAuth struct {
+	Token string
+}
+
+// Authenticate sets the Authorization header of req.
+func (a *BearerAuth) Authenticate(req *http.Request) error {
+	req.Header.Set("Authorization", "Bearer "+a.Token)
+	return nil
+}
+
+// String returns a description of the authenticator without the token.
+func (a *BearerAuth) String() string {
+	return "BearerAuth{Token:" + redacted + "}"
+}
+
+// BasicAuth sends a username and a password with the HTTP basic authentication scheme.
+type BasicAuth struct {
+	Username string
+	Password string
+}
+
+// Authenticate sets the Authorization header of req.
+func (a *BasicAuth) Authenticate(req *http.Request) error {
+	req.SetBasicAuth(a.Username, a.Password)
+	return nil
+}
+
+// String returns a description of the authenticator without the password.
+func (a *BasicAuth) String() string {
+	return "BasicAuth{Username:" + a.Username + " Password:" + redacted + "}"
+}
+
+// APIKeyAuth sends an API key in a header or a query parameter named Name.
+type APIKeyAuth struct {
+	Name  string // Header or query parameter name
+	Value string // API key
+	In    string // One of "header" or "query", default "header"
+}
+
+// Authenticate sets the API key in the header or the query of req.
+func (a *APIKeyAuth) Authenticate(req *http.Request) error {
+	switch a.In {
+	case "", APIKeyInHeader:
+		req.Header.Set(a.Name, a.Value)
+
+	case APIKeyInQuery:
+		query := req.URL.Query()
+		query.Set(a.Name, a.Value)
+		req.URL.RawQuery = query.Encode()
+
+	default:
+		return fmt.Errorf("unknown API key location %q", a.In)
+	}
+
+	return nil
+}
+
+// String returns a description of the authenticator without the API key.
+func (a *APIKeyAuth) String() string {
+	return "APIKeyAuth{Name:" + a.Name + " Value:" + redacted + " In:" + a.In + "}"
+}
+
+func (a *APIKeyAuth) redactedQueryParams() []string {
+	if a.In == APIKeyInQuery {
+		return []string{a.Name}
+	}
+
+	return nil
+}
+
+// authenticate returns a copy of req decorated by the Connector authenticator.
+// The request built by the caller is never modified.
+func (c *Connector) authenticate(req *http.Request) (*http.Request, error) {
+	if c.Authenticator == nil {
+		return req, nil
+	}
+
+	r := req.Clone(req.Context())
+	if err := c.Authenticator.Authenticate(r); err != nil {
+		return nil, fmt.Errorf("can't authenticate request: %w", err)
+	}
+
+	return r, nil
+}
+
+// doAuthenticated authenticates req and executes it with the native client.
+// When the target API answers 401 and the Connector authenticator implements
+// TokenInvalidator, the credentials are invalidated and the request is
+// authenticated and sent once more.
+func (c *Connector) doAuthenticated(req *http.Request) (*http.Response, error) {
+	invalidator, refreshable := c.Authenticator.(TokenInvalidator)
+
+	for refreshed := false; ; refreshed = true {
+		authReq, err := c.authenticate(req)
+		if err != nil {
+			return nil, err
+		}
+
+		response, err := c.Client.Do(authReq)
+		if err != nil {
+			return nil, fmt.Errorf("fail to execute HTTP request: %w", &transportError{err: c.redactError(err)})
+		}
+
+		if response.StatusCode != http.StatusUnauthorized || !refreshable || refreshed || !isReplayable(req) {
+			return response, nil
+		}
+
+		response.Body.Close()
+		invalidator.Invalidate(authReq)
+
+		if req, err = rewindRequest(req); err != nil {
+			return nil, fmt.Errorf("can't rewind request body : %w", err)
+		}
+	}
+}
+
+// redactURL returns u as a string with its password and its sensitive query
+// parameters replaced by REDACTED.
+func (c *Connector) redactURL(u *url.URL) string {
+	if u == nil {
+		return ""
+	}
+
+	sensitive := sensitiveQueryParams
+	if r, ok := c.Authenticator.(queryRedactor); ok {
+		sensitive = append(sensitive[:len(sensitive):len(sensitive)], r.redactedQueryParams()...)
+	}
+
+	redactedURL := *u
+	if u.RawQuery != "" {
+		query := u.Query()
+		for name := range query {
+			for _, s := range sensitive {
+				if strings.EqualFold(name, s) {
+					query.Set(name, redacted)
+				}
+			}
+		}
+		redactedURL.RawQuery = query.Encode()
+	}
+
+	return redactedURL.Redacted()
+}
+
+// stripAuthQuery returns a copy of u without the query parameters sent by
+// the Connector authenticator.
+func (c *Connector) stripAuthQuery(u *url.URL) *url.URL {
+	stripped := *u
+
+	r, ok := c.Authenticator.(queryRedactor)
+	if !ok || u.RawQuery == "" {
+		return &stripped
+	}
+
+	query := u.Query()
+	found := false
+	for name := range query {
+		for _, s := range r.redactedQueryParams() {
+			if strings.EqualFold(name, s) {
+				query.Del(name)
+				found = true
+			}
+		}
+	}
+	if found {
+		stripped.RawQuery = query.Encode()
+	}
+
+	return &stripped
+}
+
+// redactError redacts the URL of the *url.Error returned by the native client.
+func (c *Connector) redactError(err error) error {
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		if u, errParse := url.Parse(urlErr.URL); errParse == nil {
+			urlErr.URL = c.redactURL(u)
+		}
+	}
+
+	return err
+}
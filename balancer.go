@@ -0,0 +1,402 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+const (
+	// BalanceRoundRobin sends the requests to the endpoints in turn.
+	BalanceRoundRobin = "round_robin"
+	// BalanceRandom sends the requests to a random endpoint, chosen with a
+	// probability proportional to its weight.
+	BalanceRandom = "random"
+	// BalanceLeastInFlight sends the requests to the endpoint with the fewest
+	// requests in flight.
+	BalanceLeastInFlight = "least_in_flight"
+	// BalanceEWMA sends the requests to the endpoint with the lowest
+	// exponentially weighted moving average of its latency.
+	BalanceEWMA = "ewma"
+
+	// defaultEjectTimeout is the cool-down of an ejected endpoint when
+	// BalancerConf.EjectTimeout is not set.
+	defaultEjectTimeout = 30 * time.Second
+	// ewmaWeight is the weight of the last latency in the moving average.
+	ewmaWeight = 0.3
+)
+
+var (
+	// ErrNoEndpoint is returned when the Connector has no endpoint to send the request to.
+	ErrNoEndpoint = errors.New("no endpoint available")
+)
+
+// BalancerConf configures how the requests are spread over the endpoints of a
+// Connector given by Conf.URL and Conf.URLs. An endpoint failing at the
+// transport level is ejected for EjectTimeout, and a request whose connection
+// fails is sent to the next endpoint.
+type BalancerConf struct {
+	Strategy     string        `yaml:"strategy"`      // One of "round_robin", "random", "least_in_flight" or "ewma", default "round_robin"
+	EjectTimeout time.Duration `yaml:"eject_timeout"` // Cool-down of an endpoint failing at the transport level, default 30s
+}
+
+// validate checks the consistency of the balancer configuration.
+func (conf *BalancerConf) validate() []error {
+	if conf == nil {
+		return nil
+	}
+
+	var errs []error
+	switch conf.Strategy {
+	case "", BalanceRoundRobin, BalanceRandom, BalanceLeastInFlight, BalanceEWMA:
+	default:
+		errs = append(errs, &ConfError{
+			Field: "strategy",
+			Reason: fmt.Sprintf("unknown strategy %q, expected %s, %s, %s or %s",
+				conf.Strategy, BalanceRoundRobin, BalanceRandom, BalanceLeastInFlight, BalanceEWMA),
+		})
+	}
+	errs = append(errs, validateNotNegative("eject_timeout", conf.EjectTimeout)...)
+
+	return errs
+}
+
+// endpoint is a base URL of the target HTTP server and its statistics.
+type endpoint struct {
+	url          *url.URL
+	weight       int
+	priority     int
+	inFlight     int
+	latency      time.Duration // Moving average of the latency, zero until measured
+	ejectedUntil time.Time
+}
+
+// balancer spreads the requests over several endpoints. It is goroutine safe.
+// A nil *balancer sends the requests to Connector.URL.
+type balancer struct {
+	conf      BalancerConf
+	base      *url.URL      // Base URL of the requests built by the Connector
+	discovery *srvDiscovery // Optional source of the endpoints
+	now       func() time.Time
+
+	mu        sync.Mutex
+	endpoints []*endpoint
+	next      int // Next endpoint of the round robin strategy
+}
+
+// newBalancer returns a balancer over the endpoints of config, nil if it has
+// a single one and no SRV record.
+func newBalancer(config Conf) *balancer {
+	if config.SRV != nil {
+		return newSRVBalancer(config)
+	}
+
+	urls := config.endpointURLs()
+	if len(urls) < 2 {
+		return nil
+	}
+
+	b := &balancer{now: time.Now}
+	if config.Balancer != nil {
+		b.conf = *config.Balancer
+	}
+	if b.conf.Strategy == "" {
+		b.conf.Strategy = BalanceRoundRobin
+	}
+	if b.conf.EjectTimeout <= 0 {
+		b.conf.EjectTimeout = defaultEjectTimeout
+	}
+
+	var err error
+	if b.base, err = url.Parse(urls[0]); err != nil {
+		return nil
+	}
+
+	weighted := make([]weightedURL, 0, len(urls))
+	for _, u := range urls {
+		weighted = append(weighted, weightedURL{url: u, weight: 1})
+	}
+	b.update(weighted)
+
+	return b
+}
+
+// newSRVBalancer returns a balancer over the targets of the SRV record of config.
+func newSRVBalancer(config Conf) *balancer {
+	b := &balancer{now: time.Now, discovery: newSRVDiscovery(*config.SRV)}
+	if config.Balancer != nil {
+		b.conf = *config.Balancer
+	}
+	if b.conf.Strategy == "" {
+		b.conf.Strategy = BalanceRandom
+	}
+	if b.conf.EjectTimeout <= 0 {
+		b.conf.EjectTimeout = defaultEjectTimeout
+	}
+
+	var err error
+	if b.base, err = url.Parse(config.SRV.baseURL()); err != nil {
+		return nil
+	}
+
+	return b
+}
+
+// weightedURL is an endpoint URL, its weight for the random strategy and its
+// priority, the endpoints of the lowest priority being used first.
+type weightedURL struct {
+	url      string
+	weight   int
+	priority int
+}
+
+// update replaces the endpoints of the balancer. The statistics of the
+// endpoints still present are kept. Invalid URLs are ignored.
+func (b *balancer) update(urls []weightedURL) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	known := make(map[string]*endpoint, len(b.endpoints))
+	for _, e := range b.endpoints {
+		known[e.url.String()] = e
+	}
+
+	endpoints := make([]*endpoint, 0, len(urls))
+	for _, wu := range urls {
+		u, err := url.Parse(strings.TrimSuffix(wu.url, "/"))
+		if err != nil {
+			continue
+		}
+
+		e, ok := known[u.String()]
+		if !ok {
+			e = &endpoint{url: u}
+		}
+		e.weight = max(wu.weight, 1)
+		e.priority = wu.priority
+		endpoints = append(endpoints, e)
+	}
+
+	b.endpoints = endpoints
+	if b.next >= len(endpoints) {
+		b.next = 0
+	}
+}
+
+// pick returns the endpoint of the next attempt, skipping the tried ones, nil
+// if every endpoint was tried. Ejected endpoints are only picked when no other
+// endpoint is left.
+func (b *balancer) pick(tried map[*endpoint]bool) *endpoint {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := b.now()
+	var healthy, ejected []*endpoint
+	for i := range b.endpoints {
+		// Start from the round robin position so that ties are spread.
+		e := b.endpoints[(b.next+i)%len(b.endpoints)]
+		switch {
+		case tried[e]:
+		case now.Before(e.ejectedUntil):
+			ejected = append(ejected, e)
+		default:
+			healthy = append(healthy, e)
+		}
+	}
+
+	candidates := healthy
+	if len(candidates) == 0 {
+		candidates = ejected
+	}
+	if len(candidates) == 0 {
+		return nil
+	}
+	candidates = lowestPriority(candidates)
+
+	var e *endpoint
+	switch b.conf.Strategy {
+	case BalanceRandom:
+		e = pickWeighted(candidates)
+
+	case BalanceLeastInFlight:
+		e = candidates[0]
+		for _, c := range candidates[1:] {
+			if c.inFlight < e.inFlight {
+				e = c
+			}
+		}
+
+	case BalanceEWMA:
+		e = candidates[0]
+		for _, c := range candidates[1:] {
+			if c.latency*time.Duration(c.inFlight+1) < e.latency*time.Duration(e.inFlight+1) {
+				e = c
+			}
+		}
+
+	default:
+		e = candidates[0]
+	}
+
+	b.next = (b.next + 1) % len(b.endpoints)
+	e.inFlight++
+
+	return e
+}
+
+// lowestPriority returns the endpoints of the lowest priority.
+func lowestPriority(endpoints []*endpoint) []*endpoint {
+	priority := endpoints[0].priority
+	for _, e := range endpoints[1:] {
+		priority = min(priority, e.priority)
+	}
+
+	selected := endpoints[:0:0]
+	for _, e := range endpoints {
+		if e.priority == priority {
+			selected = append(selected, e)
+		}
+	}
+
+	return selected
+}
+
+// pickWeighted returns a random endpoint chosen with a probability proportional to its weight.
+func pickWeighted(endpoints []*endpoint) *endpoint {
+	total := 0
+	for _, e := range endpoints {
+		total += e.weight
+	}
+
+	n := rand.Intn(total) // #nosec G404 -- load balancing does not need a secure source
+	for _, e := range endpoints {
+		if n < e.weight {
+			return e
+		}
+		n -= e.weight
+	}
+
+	return endpoints[len(endpoints)-1]
+}
+
+// done records the result of an attempt sent to e.
+// The endpoint is ejected if the attempt failed at the transport level. The
+// other errors, such as an authentication failure, don't tell anything about
+// the endpoint and are ignored.
+func (b *balancer) done(e *endpoint, latency time.Duration, response *http.Response, err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	e.inFlight--
+
+	if err != nil && response == nil {
+		if isTransportError(err) && !errors.Is(err, context.Canceled) && !errors.Is(err, context.DeadlineExceeded) {
+			e.ejectedUntil = b.now().Add(b.conf.EjectTimeout)
+		}
+		return
+	}
+
+	e.ejectedUntil = time.Time{}
+	if e.latency == 0 {
+		e.latency = latency
+	} else {
+		e.latency = time.Duration(ewmaWeight*float64(latency) + (1-ewmaWeight)*float64(e.latency))
+	}
+}
+
+// rewrite returns a copy of req sent to e. Requests not built against the
+// base URL of the Connector are returned as is.
+func (b *balancer) rewrite(req *http.Request, e *endpoint) *http.Request {
+	basePath := strings.TrimSuffix(b.base.Path, "/")
+	if req.URL.Scheme != b.base.Scheme || req.URL.Host != b.base.Host || !strings.HasPrefix(req.URL.Path, basePath) {
+		return req
+	}
+
+	r := req.Clone(req.Context())
+	r.Host = ""
+	r.URL.Scheme = e.url.Scheme
+	r.URL.Host = e.url.Host
+	r.URL.Path = strings.TrimSuffix(e.url.Path, "/") + strings.TrimPrefix(req.URL.Path, basePath)
+	if req.URL.RawPath != "" {
+		r.URL.RawPath = strings.TrimSuffix(e.url.EscapedPath(), "/") +
+			strings.TrimPrefix(req.URL.RawPath, strings.TrimSuffix(b.base.EscapedPath(), "/"))
+	}
+
+	return r
+}
+
+// sendBalanced sends a single attempt of req to an endpoint chosen by the
+// balancer. When the connection to the endpoint fails, the attempt is sent to
+// the next endpoint until every endpoint was tried.
+func (c *Connector) sendBalanced(req *http.Request, exceptedStatusCode StatusMatcher) (*http.Response, error) {
+	if c.balancer == nil {
+		return c.sendOnce(req, exceptedStatusCode)
+	}
+
+	if err := c.balancer.refresh(req.Context()); err != nil {
+		return nil, fmt.Errorf("fail to execute HTTP request: %w", err)
+	}
+
+	tried := make(map[*endpoint]bool)
+	lastErr := fmt.Errorf("fail to execute HTTP request: %w", ErrNoEndpoint)
+
+	for r := req; ; {
+		e := c.balancer.pick(tried)
+		if e == nil {
+			return nil, lastErr
+		}
+		tried[e] = true
+
+		start := time.Now()
+		response, err := c.sendOnce(c.balancer.rewrite(r, e), exceptedStatusCode)
+		c.balancer.done(e, time.Since(start), response, err)
+
+		if err == nil || response != nil || !isConnectionError(err) || !isReplayable(req) || req.Context().Err() != nil {
+			return response, err
+		}
+		lastErr = err
+
+		if r, err = rewindRequest(req); err != nil {
+			return nil, fmt.Errorf("can't rewind request body : %w", err)
+		}
+	}
+}
+
+// isConnectionError reports if err happened while connecting to the target
+// HTTP server, so the request was not received and can be sent elsewhere.
+func isConnectionError(err error) bool {
+	var opErr *net.OpError
+	if errors.As(err, &opErr) && opErr.Op == "dial" {
+		return true
+	}
+
+	var dnsErr *net.DNSError
+	return errors.As(err, &dnsErr) || errors.Is(err, syscall.ECONNREFUSED)
+}
+
+// endpointURLs returns the base URLs of the target HTTP server: Conf.URL
+// followed by Conf.URLs, without duplicates, or the base URL of the SRV record.
+func (conf Conf) endpointURLs() []string {
+	if conf.SRV != nil {
+		return []string{conf.SRV.baseURL()}
+	}
+
+	var urls []string
+	seen := make(map[string]bool)
+	for _, u := range append([]string{conf.URL}, conf.URLs...) {
+		if u != "" && !seen[u] {
+			seen[u] = true
+			urls = append(urls, u)
+		}
+	}
+
+	return urls
+}
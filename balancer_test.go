@@ -0,0 +1,280 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/Aloe-Corporation/client/test"
+)
+
+func TestConnector_Balancer(t *testing.T) {
+	tests := []struct {
+		name      string
+		strategy  string
+		deadFirst bool
+		requests  int
+		wantHits  [2]int32
+	}{
+		{
+			name:     "Success case: round robin",
+			strategy: BalanceRoundRobin,
+			requests: 4,
+			wantHits: [2]int32{2, 2},
+		},
+		{
+			name:      "Success case: failover and ejection",
+			strategy:  BalanceRoundRobin,
+			deadFirst: true,
+			requests:  4,
+			wantHits:  [2]int32{0, 4},
+		},
+		{
+			name:      "Success case: least in flight failover",
+			strategy:  BalanceLeastInFlight,
+			deadFirst: true,
+			requests:  3,
+			wantHits:  [2]int32{0, 3},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			first, firstHits := test.FlakyEndpoint(0, http.StatusOK, "")
+			defer first.Close()
+			second, secondHits := test.FlakyEndpoint(0, http.StatusOK, "")
+			defer second.Close()
+			if tt.deadFirst {
+				first.Close()
+			}
+
+			c := factoryConnector(Conf{
+				URL:          first.URL,
+				URLs:         []string{second.URL},
+				PingEndpoint: "/",
+				Balancer:     &BalancerConf{Strategy: tt.strategy},
+			}, FactoryHTTPClient())
+
+			for i := 0; i < tt.requests; i++ {
+				if _, err := c.SimpleGet("/data"); err != nil {
+					t.Fatalf("Connector.SimpleGet() error = %v", err)
+				}
+			}
+
+			if got := [2]int32{firstHits.Load(), secondHits.Load()}; got != tt.wantHits {
+				t.Errorf("hits = %v, want %v", got, tt.wantHits)
+			}
+		})
+	}
+}
+
+func TestConnector_Balancer_AllDown(t *testing.T) {
+	first, _ := test.FlakyEndpoint(0, http.StatusOK, "")
+	first.Close()
+	second, _ := test.FlakyEndpoint(0, http.StatusOK, "")
+	second.Close()
+
+	c := factoryConnector(Conf{
+		URLs:         []string{first.URL, second.URL},
+		PingEndpoint: "/",
+	}, FactoryHTTPClient())
+
+	_, err := c.SimpleGet("/data")
+	if err == nil || !isConnectionError(err) {
+		t.Errorf("Connector.SimpleGet() error = %v, want a connection error", err)
+	}
+}
+
+func TestBalancer_pick(t *testing.T) {
+	tests := []struct {
+		name     string
+		strategy string
+		inFlight [3]int
+		latency  [3]time.Duration
+		ejected  [3]bool
+		tried    [3]bool
+		want     int
+	}{
+		{
+			name:     "Success case: least in flight",
+			strategy: BalanceLeastInFlight,
+			inFlight: [3]int{2, 0, 1},
+			want:     1,
+		},
+		{
+			name:     "Success case: ewma",
+			strategy: BalanceEWMA,
+			latency:  [3]time.Duration{30 * time.Millisecond, 20 * time.Millisecond, 10 * time.Millisecond},
+			want:     2,
+		},
+		{
+			name:     "Success case: ewma weighted by in flight requests",
+			strategy: BalanceEWMA,
+			inFlight: [3]int{0, 0, 3},
+			latency:  [3]time.Duration{30 * time.Millisecond, 20 * time.Millisecond, 10 * time.Millisecond},
+			want:     1,
+		},
+		{
+			name:     "Success case: ejected endpoints skipped",
+			strategy: BalanceRoundRobin,
+			ejected:  [3]bool{true, true, false},
+			want:     2,
+		},
+		{
+			name:     "Success case: ejected endpoint when no other left",
+			strategy: BalanceRoundRobin,
+			ejected:  [3]bool{false, true, false},
+			tried:    [3]bool{true, false, true},
+			want:     1,
+		},
+		{
+			name:     "Fail case: every endpoint tried",
+			strategy: BalanceRandom,
+			tried:    [3]bool{true, true, true},
+			want:     -1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			now := time.Now()
+			b := newBalancer(Conf{
+				URLs:     []string{"https://a.myserver.com", "https://b.myserver.com", "https://c.myserver.com"},
+				Balancer: &BalancerConf{Strategy: tt.strategy},
+			})
+			b.now = func() time.Time { return now }
+
+			tried := make(map[*endpoint]bool)
+			for i, e := range b.endpoints {
+				e.inFlight = tt.inFlight[i]
+				e.latency = tt.latency[i]
+				if tt.ejected[i] {
+					e.ejectedUntil = now.Add(time.Second)
+				}
+				if tt.tried[i] {
+					tried[e] = true
+				}
+			}
+
+			got := b.pick(tried)
+			if tt.want < 0 {
+				if got != nil {
+					t.Errorf("balancer.pick() = %v, want nil", got.url)
+				}
+				return
+			}
+			if got != b.endpoints[tt.want] {
+				t.Errorf("balancer.pick() = %v, want %v", got.url, b.endpoints[tt.want].url)
+			}
+		})
+	}
+}
+
+func TestBalancer_done(t *testing.T) {
+	refused := &transportError{err: &net.OpError{Op: "dial", Err: syscall.ECONNREFUSED}}
+
+	tests := []struct {
+		name        string
+		response    *http.Response
+		err         error
+		wantEjected bool
+	}{
+		{
+			name:     "Success case: response",
+			response: &http.Response{StatusCode: http.StatusOK},
+		},
+		{
+			name:        "Success case: transport error",
+			err:         fmt.Errorf("fail to execute HTTP request: %w", refused),
+			wantEjected: true,
+		},
+		{
+			name: "Fail case: authentication error",
+			err:  fmt.Errorf("can't authenticate request: %w", errors.New("token endpoint unavailable")),
+		},
+		{
+			name: "Fail case: body rewind error",
+			err:  fmt.Errorf("can't rewind request body : %w", errors.New("no body")),
+		},
+		{
+			name: "Fail case: canceled request",
+			err:  fmt.Errorf("fail to execute HTTP request: %w", &transportError{err: context.Canceled}),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := newBalancer(Conf{URLs: []string{"https://a.myserver.com", "https://b.myserver.com"}})
+			e := b.endpoints[0]
+			e.inFlight = 1
+
+			b.done(e, time.Millisecond, tt.response, tt.err)
+
+			if ejected := !e.ejectedUntil.IsZero(); ejected != tt.wantEjected {
+				t.Errorf("balancer.done() ejected = %v, want %v", ejected, tt.wantEjected)
+			}
+			if e.inFlight != 0 {
+				t.Errorf("balancer.done() in flight = %d, want 0", e.inFlight)
+			}
+		})
+	}
+}
+
+func TestBalancer_rewrite(t *testing.T) {
+	tests := []struct {
+		name string
+		req  string
+		want string
+	}{
+		{
+			name: "Success case: path prefix",
+			req:  "https://a.myserver.com/api/users?page=2",
+			want: "https://b.myserver.com/v2/users?page=2",
+		},
+		{
+			name: "Success case: escaped path",
+			req:  "https://a.myserver.com/api/users/a%2Fb",
+			want: "https://b.myserver.com/v2/users/a%2Fb",
+		},
+		{
+			name: "Success case: other host untouched",
+			req:  "https://other.myserver.com/api/users",
+			want: "https://other.myserver.com/api/users",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := newBalancer(Conf{URLs: []string{"https://a.myserver.com/api", "https://b.myserver.com/v2/"}})
+
+			req, err := http.NewRequest(http.MethodGet, tt.req, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got := b.rewrite(req, b.endpoints[1])
+			if got.URL.String() != tt.want {
+				t.Errorf("balancer.rewrite() = %v, want %v", got.URL, tt.want)
+			}
+			if got != req && req.URL.String() != tt.req {
+				t.Errorf("balancer.rewrite() modified the request URL to %v", req.URL)
+			}
+		})
+	}
+}
+
+func TestIsConnectionError(t *testing.T) {
+	server, _ := test.FlakyEndpoint(0, http.StatusOK, "")
+	server.Close()
+
+	_, err := FactoryHTTPClient().Get(server.URL)
+	if !isConnectionError(err) {
+		t.Errorf("isConnectionError(%v) = false, want true", err)
+	}
+
+	if isConnectionError(&url.Error{Op: "Get", URL: server.URL, Err: errors.New("unexpected EOF")}) {
+		t.Error("isConnectionError() = true, want false")
+	}
+}
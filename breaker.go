@@ -0,0 +1,256 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const (
+	// defaultFailureThreshold is the number of consecutive failures opening the circuit
+	// when CircuitBreakerConf.FailureThreshold is not set.
+	defaultFailureThreshold = 5
+	// defaultOpenTimeout is the cool-down of an open circuit when CircuitBreakerConf.OpenTimeout is not set.
+	defaultOpenTimeout = 30 * time.Second
+	// defaultHalfOpenProbes is the number of probes of an half-open circuit when
+	// CircuitBreakerConf.HalfOpenProbes is not set.
+	defaultHalfOpenProbes = 1
+)
+
+var (
+	// ErrCircuitOpen is returned without sending the request while the circuit breaker is open.
+	ErrCircuitOpen = errors.New("circuit breaker is open")
+)
+
+// CircuitState is the state of a circuit breaker.
+type CircuitState int
+
+const (
+	// CircuitClosed lets every request through.
+	CircuitClosed CircuitState = iota
+	// CircuitOpen rejects every request with ErrCircuitOpen.
+	CircuitOpen
+	// CircuitHalfOpen lets a limited number of probe requests through to
+	// decide if the circuit can be closed.
+	CircuitHalfOpen
+)
+
+// String returns the name of the state.
+func (s CircuitState) String() string {
+	switch s {
+	case CircuitClosed:
+		return "closed"
+	case CircuitOpen:
+		return "open"
+	case CircuitHalfOpen:
+		return "half-open"
+	default:
+		return "unknown"
+	}
+}
+
+// CircuitBreakerConf configures the circuit breaker of a Connector.
+// The circuit opens after FailureThreshold consecutive failures. Once OpenTimeout
+// is elapsed, it becomes half-open and lets HalfOpenProbes requests through: the
+// circuit closes if they all succeed and opens again on the first failure.
+type CircuitBreakerConf struct {
+	FailureThreshold int                         `yaml:"failure_threshold"` // Consecutive failures opening the circuit, default 5
+	OpenTimeout      time.Duration               `yaml:"open_timeout"`      // Cool-down before the circuit becomes half-open, default 30s
+	HalfOpenProbes   int                         `yaml:"half_open_probes"`  // Probe requests allowed while half-open, default 1
+	OnStateChange    func(from, to CircuitState) `yaml:"-"`                 // Optional callback called on every state change
+	IsFailure        func(error) bool            `yaml:"-"`                 // Classifies request errors, default IsCircuitFailure
+}
+
+// validate checks the consistency of the circuit breaker configuration.
+func (conf *CircuitBreakerConf) validate() []error {
+	if conf == nil {
+		return nil
+	}
+
+	var errs []error
+	errs = append(errs, validateNotNegative("failure_threshold", conf.FailureThreshold)...)
+	errs = append(errs, validateNotNegative("open_timeout", conf.OpenTimeout)...)
+	errs = append(errs, validateNotNegative("half_open_probes", conf.HalfOpenProbes)...)
+
+	return errs
+}
+
+// IsCircuitFailure reports if err is a failure of the target API:
+// a transport error or a *FailRequestError with a 5xx or 429 status code.
+// Client errors such as 404 and context cancellation are not failures.
+func IsCircuitFailure(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+
+	var failErr *FailRequestError
+	if errors.As(err, &failErr) {
+		return IsServerError(failErr) || failErr.Code == http.StatusTooManyRequests
+	}
+
+	return true
+}
+
+// circuitBreaker is a goroutine safe implementation of the circuit breaker pattern.
+// A nil *circuitBreaker lets every request through.
+type circuitBreaker struct {
+	conf CircuitBreakerConf
+	now  func() time.Time
+
+	mu         sync.Mutex
+	state      CircuitState
+	failures   int       // Consecutive failures while closed
+	openedAt   time.Time // Last time the circuit opened
+	probes     int       // Probes in flight while half-open
+	successes  int       // Successful probes while half-open
+	generation uint64    // Incremented on every state change
+}
+
+// newCircuitBreaker returns a circuit breaker configured by conf, nil if conf is nil.
+func newCircuitBreaker(conf *CircuitBreakerConf) *circuitBreaker {
+	if conf == nil {
+		return nil
+	}
+
+	b := &circuitBreaker{
+		conf: *conf,
+		now:  time.Now,
+	}
+	if b.conf.FailureThreshold <= 0 {
+		b.conf.FailureThreshold = defaultFailureThreshold
+	}
+	if b.conf.OpenTimeout <= 0 {
+		b.conf.OpenTimeout = defaultOpenTimeout
+	}
+	if b.conf.HalfOpenProbes <= 0 {
+		b.conf.HalfOpenProbes = defaultHalfOpenProbes
+	}
+	if b.conf.IsFailure == nil {
+		b.conf.IsFailure = IsCircuitFailure
+	}
+
+	return b
+}
+
+// State returns the current state of the circuit.
+func (b *circuitBreaker) State() CircuitState {
+	if b == nil {
+		return CircuitClosed
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.state == CircuitOpen && b.now().Sub(b.openedAt) >= b.conf.OpenTimeout {
+		return CircuitHalfOpen
+	}
+
+	return b.state
+}
+
+// allow returns ErrCircuitOpen if the request must not be sent.
+// Every allowed request must be followed by a call to circuitBreaker.record
+// with the returned generation.
+func (b *circuitBreaker) allow() (uint64, error) {
+	if b == nil {
+		return 0, nil
+	}
+
+	b.mu.Lock()
+	from := b.state
+
+	if b.state == CircuitOpen {
+		if b.now().Sub(b.openedAt) < b.conf.OpenTimeout {
+			b.mu.Unlock()
+			return 0, ErrCircuitOpen
+		}
+		b.setState(CircuitHalfOpen)
+	}
+
+	if b.state == CircuitHalfOpen {
+		if b.probes >= b.conf.HalfOpenProbes {
+			b.mu.Unlock()
+			b.notify(from, CircuitHalfOpen)
+			return 0, ErrCircuitOpen
+		}
+		b.probes++
+	}
+
+	to, generation := b.state, b.generation
+	b.mu.Unlock()
+	b.notify(from, to)
+
+	return generation, nil
+}
+
+// record updates the circuit with the result of a request allowed in the
+// given generation. The results of requests allowed before the last state
+// change are ignored, so a request sent while closed can't count as a probe.
+func (b *circuitBreaker) record(generation uint64, err error) {
+	if b == nil {
+		return
+	}
+
+	b.mu.Lock()
+	if generation != b.generation {
+		b.mu.Unlock()
+		return
+	}
+	from := b.state
+
+	canceled := errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded)
+	failure := !canceled && b.conf.IsFailure(err)
+
+	switch b.state {
+	case CircuitClosed:
+		if failure {
+			b.failures++
+			if b.failures >= b.conf.FailureThreshold {
+				b.setState(CircuitOpen)
+			}
+		} else if !canceled {
+			b.failures = 0
+		}
+
+	case CircuitHalfOpen:
+		if b.probes > 0 {
+			b.probes--
+		}
+		switch {
+		case failure:
+			b.setState(CircuitOpen)
+		case !canceled:
+			b.successes++
+			if b.successes >= b.conf.HalfOpenProbes {
+				b.setState(CircuitClosed)
+			}
+		}
+	}
+
+	to := b.state
+	b.mu.Unlock()
+	b.notify(from, to)
+}
+
+// setState moves the circuit to state and resets the counters.
+// The caller must hold the lock.
+func (b *circuitBreaker) setState(state CircuitState) {
+	b.state = state
+	b.generation++
+	b.failures = 0
+	b.probes = 0
+	b.successes = 0
+	if state == CircuitOpen {
+		b.openedAt = b.now()
+	}
+}
+
+// notify calls the OnStateChange callback if the state changed.
+// It must be called without holding the lock.
+func (b *circuitBreaker) notify(from, to CircuitState) {
+	if from != to && b.conf.OnStateChange != nil {
+		b.conf.OnStateChange(from, to)
+	}
+}
@@ -0,0 +1,172 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/Aloe-Corporation/client/test"
+)
+
+func TestCircuitBreaker(t *testing.T) {
+	errFailure := &FailRequestError{Code: http.StatusInternalServerError}
+
+	type step struct {
+		elapsed   time.Duration // Time elapsed since the beginning of the test
+		err       error         // Result of the request, ignored if the request is rejected
+		wantAllow bool
+		wantState CircuitState // State after the request
+	}
+	tests := []struct {
+		name            string
+		conf            CircuitBreakerConf
+		steps           []step
+		wantTransitions []CircuitState
+	}{
+		{
+			name: "Success case: opens after threshold",
+			conf: CircuitBreakerConf{FailureThreshold: 2, OpenTimeout: time.Minute},
+			steps: []step{
+				{err: errFailure, wantAllow: true, wantState: CircuitClosed},
+				{err: errFailure, wantAllow: true, wantState: CircuitOpen},
+				{elapsed: time.Second, wantAllow: false, wantState: CircuitOpen},
+			},
+			wantTransitions: []CircuitState{CircuitOpen},
+		},
+		{
+			name: "Success case: success resets the failure count",
+			conf: CircuitBreakerConf{FailureThreshold: 2},
+			steps: []step{
+				{err: errFailure, wantAllow: true, wantState: CircuitClosed},
+				{err: nil, wantAllow: true, wantState: CircuitClosed},
+				{err: errFailure, wantAllow: true, wantState: CircuitClosed},
+			},
+		},
+		{
+			name: "Success case: client errors and cancellation are not failures",
+			conf: CircuitBreakerConf{FailureThreshold: 1},
+			steps: []step{
+				{err: &FailRequestError{Code: http.StatusNotFound}, wantAllow: true, wantState: CircuitClosed},
+				{err: context.Canceled, wantAllow: true, wantState: CircuitClosed},
+			},
+		},
+		{
+			name: "Success case: half-open probe closes the circuit",
+			conf: CircuitBreakerConf{FailureThreshold: 1, OpenTimeout: time.Minute, HalfOpenProbes: 1},
+			steps: []step{
+				{err: errFailure, wantAllow: true, wantState: CircuitOpen},
+				{elapsed: time.Minute, err: nil, wantAllow: true, wantState: CircuitClosed},
+			},
+			wantTransitions: []CircuitState{CircuitOpen, CircuitHalfOpen, CircuitClosed},
+		},
+		{
+			name: "Fail case: half-open probe failure opens the circuit again",
+			conf: CircuitBreakerConf{FailureThreshold: 1, OpenTimeout: time.Minute},
+			steps: []step{
+				{err: errFailure, wantAllow: true, wantState: CircuitOpen},
+				{elapsed: time.Minute, err: errors.New("connection refused"), wantAllow: true, wantState: CircuitOpen},
+				{elapsed: time.Minute + time.Second, wantAllow: false, wantState: CircuitOpen},
+			},
+			wantTransitions: []CircuitState{CircuitOpen, CircuitHalfOpen, CircuitOpen},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			start := time.Now()
+			now := start
+			var transitions []CircuitState
+			tt.conf.OnStateChange = func(_, to CircuitState) {
+				transitions = append(transitions, to)
+			}
+
+			b := newCircuitBreaker(&tt.conf)
+			b.now = func() time.Time { return now }
+
+			for i, s := range tt.steps {
+				now = start.Add(s.elapsed)
+				generation, err := b.allow()
+				if (err == nil) != s.wantAllow {
+					t.Fatalf("step %d: circuitBreaker.allow() error = %v, wantAllow %v", i, err, s.wantAllow)
+				}
+				if err == nil {
+					b.record(generation, s.err)
+				}
+				if got := b.State(); got != s.wantState {
+					t.Fatalf("step %d: circuitBreaker.State() = %v, want %v", i, got, s.wantState)
+				}
+			}
+
+			if !reflect.DeepEqual(transitions, tt.wantTransitions) {
+				t.Errorf("OnStateChange transitions = %v, want %v", transitions, tt.wantTransitions)
+			}
+		})
+	}
+}
+
+func TestCircuitBreaker_StaleResult(t *testing.T) {
+	start := time.Now()
+	now := start
+
+	b := newCircuitBreaker(&CircuitBreakerConf{FailureThreshold: 1, OpenTimeout: time.Minute, HalfOpenProbes: 2})
+	b.now = func() time.Time { return now }
+
+	// A request allowed while closed finishes after the circuit went half-open.
+	stale, err := b.allow()
+	if err != nil {
+		t.Fatalf("circuitBreaker.allow() error = %v", err)
+	}
+	generation, _ := b.allow()
+	b.record(generation, &FailRequestError{Code: http.StatusInternalServerError})
+
+	now = start.Add(time.Minute)
+	probe, err := b.allow()
+	if err != nil {
+		t.Fatalf("circuitBreaker.allow() error = %v", err)
+	}
+
+	b.record(stale, nil)
+
+	// The stale result neither released a probe slot nor counted as a success.
+	if _, err := b.allow(); err != nil {
+		t.Fatalf("circuitBreaker.allow() error = %v", err)
+	}
+	if _, err := b.allow(); !errors.Is(err, ErrCircuitOpen) {
+		t.Errorf("circuitBreaker.allow() error = %v, want %v", err, ErrCircuitOpen)
+	}
+	b.record(probe, nil)
+	if got := b.State(); got != CircuitHalfOpen {
+		t.Errorf("circuitBreaker.State() = %v, want %v", got, CircuitHalfOpen)
+	}
+}
+
+func TestConnector_DoWithStatusCheck_CircuitBreaker(t *testing.T) {
+	server, counter := test.FlakyEndpoint(10, http.StatusServiceUnavailable, "")
+	defer server.Close()
+
+	c := &Connector{
+		Client:  FactoryHTTPClient(),
+		URL:     server.URL,
+		breaker: newCircuitBreaker(&CircuitBreakerConf{FailureThreshold: 2, OpenTimeout: time.Minute}),
+	}
+
+	for i := 0; i < 2; i++ {
+		if _, err := c.SimpleGet("/"); err == nil || errors.Is(err, ErrCircuitOpen) {
+			t.Fatalf("Connector.SimpleGet() error = %v, want a FailRequestError", err)
+		}
+	}
+
+	if got := c.CircuitState(); got != CircuitOpen {
+		t.Fatalf("Connector.CircuitState() = %v, want %v", got, CircuitOpen)
+	}
+
+	if _, err := c.SimpleGet("/"); !errors.Is(err, ErrCircuitOpen) {
+		t.Errorf("Connector.SimpleGet() error = %v, want %v", err, ErrCircuitOpen)
+	}
+
+	if got := counter.Load(); got != 2 {
+		t.Errorf("Connector.SimpleGet() sent %d requests, want 2", got)
+	}
+}
@@ -0,0 +1,479 @@
+package client
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// CacheMemory selects the MemoryCache storage in CacheConf.
+	CacheMemory = "memory"
+	// CacheDisk selects the DiskCache storage in CacheConf.
+	CacheDisk = "disk"
+
+	// defaultCacheMaxBytes is the size bound of the memory storage when CacheConf.MaxBytes is not set.
+	defaultCacheMaxBytes = 32 << 20
+	// heuristicFreshnessRatio is the fraction of the time elapsed since the
+	// Last-Modified date used as freshness lifetime when the response has no
+	// explicit expiration time, as suggested by RFC 9111 section 4.2.2.
+	heuristicFreshnessRatio = 0.1
+)
+
+var (
+	// cacheableStatusCodes are the status codes of the responses the cache stores.
+	cacheableStatusCodes = map[int]bool{
+		http.StatusOK:                   true,
+		http.StatusNonAuthoritativeInfo: true,
+		http.StatusNoContent:            true,
+		http.StatusMultipleChoices:      true,
+		http.StatusMovedPermanently:     true,
+		http.StatusPermanentRedirect:    true,
+		http.StatusNotFound:             true,
+		http.StatusMethodNotAllowed:     true,
+		http.StatusGone:                 true,
+		http.StatusRequestURITooLong:    true,
+		http.StatusNotImplemented:       true,
+	}
+)
+
+// CacheConf configures the private response cache of a Connector.
+// Only GET requests are served from the cache, following RFC 9111: responses
+// are stored according to their Cache-Control, Expires, Vary, ETag and
+// Last-Modified headers and stale responses are revalidated with conditional
+// requests. Successful unsafe requests invalidate the cached responses of
+// their URL. Cached GET responses are read entirely before being returned,
+// including by Connector.DoStream.
+// Requests carrying their own Authorization or Cookie header bypass the cache,
+// so a response fetched with the credentials of a caller is never served to
+// another one. The credentials of Connector.Authenticator are the same for
+// every request and don't prevent caching.
+type CacheConf struct {
+	Store    string       `yaml:"store"`     // One of "memory" or "disk", default "memory"
+	MaxBytes int64        `yaml:"max_bytes"` // Size bound of the memory storage, default 32 MiB
+	Dir      string       `yaml:"dir"`       // Directory of the disk storage
+	Storage  CacheStorage `yaml:"-"`         // Optional storage replacing the one selected by Store
+}
+
+// validate checks the consistency of the cache configuration.
+func (conf *CacheConf) validate() []error {
+	if conf == nil {
+		return nil
+	}
+
+	var errs []error
+	switch conf.Store {
+	case "", CacheMemory:
+	case CacheDisk:
+		if conf.Dir == "" && conf.Storage == nil {
+			errs = append(errs, &ConfError{Field: "dir", Reason: "is required by the disk storage"})
+		}
+	default:
+		errs = append(errs, &ConfError{
+			Field:  "store",
+			Reason: fmt.Sprintf("unknown store %q, expected %s or %s", conf.Store, CacheMemory, CacheDisk),
+		})
+	}
+	errs = append(errs, validateNotNegative("max_bytes", conf.MaxBytes)...)
+
+	return errs
+}
+
+// CacheStats counts the outcomes of the GET requests handled by the response cache.
+type CacheStats struct {
+	Hits          int64 // Requests served from the cache without contacting the target API
+	Misses        int64 // Requests sent to the target API, including failed revalidations
+	Revalidations int64 // Requests served from the cache after a 304 Not Modified response
+}
+
+// responseCache is the private HTTP cache of a Connector.
+// A nil *responseCache does not cache anything.
+type responseCache struct {
+	storage CacheStorage
+	now     func() time.Time
+
+	hits          atomic.Int64
+	misses        atomic.Int64
+	revalidations atomic.Int64
+}
+
+// newResponseCache returns a response cache configured by conf, nil if conf is nil.
+func newResponseCache(conf *CacheConf) *responseCache {
+	if conf == nil {
+		return nil
+	}
+
+	storage := conf.Storage
+	if storage == nil {
+		switch conf.Store {
+		case CacheDisk:
+			storage = NewDiskCache(conf.Dir)
+		default:
+			maxBytes := conf.MaxBytes
+			if maxBytes <= 0 {
+				maxBytes = defaultCacheMaxBytes
+			}
+			storage = NewMemoryCache(maxBytes)
+		}
+	}
+
+	return &responseCache{storage: storage, now: time.Now}
+}
+
+// CacheStats returns the statistics of the response cache.
+// They are always zero when no cache is configured.
+func (c *Connector) CacheStats() CacheStats {
+	if c.cache == nil {
+		return CacheStats{}
+	}
+
+	return CacheStats{
+		Hits:          c.cache.hits.Load(),
+		Misses:        c.cache.misses.Load(),
+		Revalidations: c.cache.revalidations.Load(),
+	}
+}
+
+// accepts reports if req can be served from the cache. Requests already
+// carrying conditional or range headers bypass the cache.
+func (rc *responseCache) accepts(req *http.Request) bool {
+	if rc == nil || req.Method != http.MethodGet {
+		return false
+	}
+
+	for _, name := range []string{"Authorization", "Cookie", "If-None-Match", "If-Modified-Since", "If-Match", "If-Unmodified-Since", "Range"} {
+		if req.Header.Get(name) != "" {
+			return false
+		}
+	}
+
+	_, noStore := parseCacheControl(req.Header)["no-store"]
+
+	return !noStore
+}
+
+// sendCached serves the GET request req from the cache when a fresh response
+// is stored, revalidates a stale one and otherwise sends the request and
+// stores its response.
+func (c *Connector) sendCached(req *http.Request, exceptedStatusCode StatusMatcher) (*http.Response, error) {
+	rc := c.cache
+	key := req.URL.String()
+
+	if entry, ok := rc.load(key, req); ok && exceptedStatusCode.Match(entry.StatusCode) {
+		age := entry.age(rc.now())
+		if entry.fresh(req, age) {
+			rc.hits.Add(1)
+			return entry.response(req, age), nil
+		}
+
+		if entry.Header.Get("ETag") != "" || entry.Header.Get("Last-Modified") != "" {
+			return c.revalidate(req, exceptedStatusCode, key, entry)
+		}
+	}
+
+	rc.misses.Add(1)
+
+	requestTime := rc.now()
+	response, err := c.sendWithRetry(req, exceptedStatusCode)
+	if err != nil {
+		return nil, err
+	}
+
+	return rc.store(key, req, response, requestTime)
+}
+
+// revalidate sends a conditional request for the stale entry. The entry is
+// served again if the target API answers 304 Not Modified.
+func (c *Connector) revalidate(req *http.Request, exceptedStatusCode StatusMatcher, key string, entry *cacheEntry) (*http.Response, error) {
+	rc := c.cache
+
+	r := req.Clone(req.Context())
+	if r.Header == nil {
+		r.Header = make(http.Header)
+	}
+	if etag := entry.Header.Get("ETag"); etag != "" {
+		r.Header.Set("If-None-Match", etag)
+	}
+	if lastModified := entry.Header.Get("Last-Modified"); lastModified != "" {
+		r.Header.Set("If-Modified-Since", lastModified)
+	}
+
+	requestTime := rc.now()
+	response, err := c.sendWithRetry(r, exceptedStatusCode)
+
+	var failErr *FailRequestError
+	switch {
+	case err == nil && response.StatusCode == http.StatusNotModified:
+		response.Body.Close()
+		// RFC 9111 section 4.3.4: the stored headers are updated with the
+		// headers of the 304 response.
+		for name, values := range response.Header {
+			if name != "Content-Length" {
+				entry.Header[name] = values
+			}
+		}
+
+	case errors.As(err, &failErr) && failErr.Code == http.StatusNotModified:
+
+	default:
+		rc.misses.Add(1)
+		if err != nil {
+			return nil, err
+		}
+		return rc.store(key, req, response, requestTime)
+	}
+
+	rc.revalidations.Add(1)
+	entry.RequestTime = requestTime
+	entry.ResponseTime = rc.now()
+	entry.Header.Del("Age")
+	rc.save(key, entry)
+
+	return entry.response(req, entry.age(entry.ResponseTime)), nil
+}
+
+// store saves response in the cache if it is storable. The body of the
+// returned response is read from memory when the response was stored.
+func (rc *responseCache) store(key string, req *http.Request, response *http.Response, requestTime time.Time) (*http.Response, error) {
+	if !storable(response) {
+		return response, nil
+	}
+
+	body, err := io.ReadAll(response.Body)
+	response.Body.Close()
+	if err != nil {
+		return nil, fmt.Errorf("can't read response body : %w", err)
+	}
+
+	entry := &cacheEntry{
+		StatusCode:   response.StatusCode,
+		Header:       response.Header.Clone(),
+		Body:         body,
+		Vary:         make(map[string]string),
+		RequestTime:  requestTime,
+		ResponseTime: rc.now(),
+	}
+	for _, name := range varyFields(response.Header) {
+		entry.Vary[name] = strings.Join(req.Header.Values(name), ",")
+	}
+	rc.save(key, entry)
+
+	response.Body = io.NopCloser(bytes.NewReader(body))
+
+	return response, nil
+}
+
+// invalidate removes the responses invalidated by the successful unsafe
+// request req from the cache: the ones of its URL and of the URLs of its
+// Location and Content-Location headers on the same host.
+func (rc *responseCache) invalidate(req *http.Request, response *http.Response) {
+	if rc == nil {
+		return
+	}
+
+	switch req.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace:
+		return
+	}
+
+	rc.storage.Delete(req.URL.String())
+	for _, name := range []string{"Location", "Content-Location"} {
+		location, err := req.URL.Parse(response.Header.Get(name))
+		if err == nil && response.Header.Get(name) != "" && location.Host == req.URL.Host {
+			rc.storage.Delete(location.String())
+		}
+	}
+}
+
+// load returns the entry stored at key if it matches the Vary headers of req.
+func (rc *responseCache) load(key string, req *http.Request) (*cacheEntry, bool) {
+	data, ok := rc.storage.Get(key)
+	if !ok {
+		return nil, false
+	}
+
+	var entry cacheEntry
+	if err := json.Unmarshal(data, &entry); err != nil {
+		rc.storage.Delete(key)
+		return nil, false
+	}
+
+	for name, value := range entry.Vary {
+		if strings.Join(req.Header.Values(name), ",") != value {
+			return nil, false
+		}
+	}
+
+	return &entry, true
+}
+
+// save serializes entry into the storage.
+func (rc *responseCache) save(key string, entry *cacheEntry) {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return
+	}
+
+	rc.storage.Set(key, data)
+}
+
+// cacheEntry is a stored response.
+type cacheEntry struct {
+	StatusCode   int               `json:"status_code"`
+	Header       http.Header       `json:"header"`
+	Body         []byte            `json:"body"`
+	Vary         map[string]string `json:"vary"` // Values of the request headers selected by Vary
+	RequestTime  time.Time         `json:"request_time"`
+	ResponseTime time.Time         `json:"response_time"`
+}
+
+// age returns the current age of the entry, following RFC 9111 section 4.2.3.
+func (e *cacheEntry) age(now time.Time) time.Duration {
+	date := e.date()
+	apparentAge := max(e.ResponseTime.Sub(date), 0)
+
+	var ageValue time.Duration
+	if seconds, err := strconv.Atoi(e.Header.Get("Age")); err == nil && seconds > 0 {
+		ageValue = time.Duration(seconds) * time.Second
+	}
+	correctedAge := ageValue + e.ResponseTime.Sub(e.RequestTime)
+
+	return max(apparentAge, correctedAge) + now.Sub(e.ResponseTime)
+}
+
+// freshnessLifetime returns the time the entry stays fresh after its
+// generation, following RFC 9111 section 4.2.1.
+func (e *cacheEntry) freshnessLifetime() time.Duration {
+	if maxAge, ok := parseCacheControl(e.Header)["max-age"]; ok {
+		seconds, err := strconv.Atoi(maxAge)
+		if err != nil || seconds < 0 {
+			return 0
+		}
+		return time.Duration(seconds) * time.Second
+	}
+
+	if expires := e.Header.Get("Expires"); expires != "" {
+		t, err := http.ParseTime(expires)
+		if err != nil {
+			return 0
+		}
+		return max(t.Sub(e.date()), 0)
+	}
+
+	if lastModified, err := http.ParseTime(e.Header.Get("Last-Modified")); err == nil {
+		return time.Duration(heuristicFreshnessRatio * float64(max(e.date().Sub(lastModified), 0)))
+	}
+
+	return 0
+}
+
+// fresh reports if the entry of the given age can be served to req without
+// revalidation.
+func (e *cacheEntry) fresh(req *http.Request, age time.Duration) bool {
+	if _, noCache := parseCacheControl(e.Header)["no-cache"]; noCache {
+		return false
+	}
+
+	requestDirectives := parseCacheControl(req.Header)
+	if _, noCache := requestDirectives["no-cache"]; noCache {
+		return false
+	}
+	if maxAge, ok := requestDirectives["max-age"]; ok {
+		if seconds, err := strconv.Atoi(maxAge); err == nil && age > time.Duration(seconds)*time.Second {
+			return false
+		}
+	}
+
+	return age < e.freshnessLifetime()
+}
+
+// date returns the Date header of the entry, its response time if missing.
+func (e *cacheEntry) date() time.Time {
+	if date, err := http.ParseTime(e.Header.Get("Date")); err == nil {
+		return date
+	}
+
+	return e.ResponseTime
+}
+
+// response returns the entry as a response to req.
+func (e *cacheEntry) response(req *http.Request, age time.Duration) *http.Response {
+	header := e.Header.Clone()
+	header.Set("Age", strconv.Itoa(int(age.Seconds())))
+
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", e.StatusCode, http.StatusText(e.StatusCode)),
+		StatusCode:    e.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader(e.Body)),
+		ContentLength: int64(len(e.Body)),
+		Request:       req,
+	}
+}
+
+// storable reports if response can be stored, following RFC 9111 section 3.
+func storable(response *http.Response) bool {
+	if !cacheableStatusCodes[response.StatusCode] {
+		return false
+	}
+
+	directives := parseCacheControl(response.Header)
+	if _, noStore := directives["no-store"]; noStore {
+		return false
+	}
+
+	for _, name := range varyFields(response.Header) {
+		if name == "*" {
+			return false
+		}
+	}
+
+	_, maxAge := directives["max-age"]
+	_, noCache := directives["no-cache"]
+
+	return maxAge || noCache ||
+		response.Header.Get("Expires") != "" ||
+		response.Header.Get("ETag") != "" ||
+		response.Header.Get("Last-Modified") != ""
+}
+
+// varyFields returns the canonical names of the header fields listed by the Vary header.
+func varyFields(header http.Header) []string {
+	var fields []string
+	for _, value := range header.Values("Vary") {
+		for _, name := range strings.Split(value, ",") {
+			if name = strings.TrimSpace(name); name != "" {
+				fields = append(fields, http.CanonicalHeaderKey(name))
+			}
+		}
+	}
+
+	return fields
+}
+
+// parseCacheControl returns the directives of the Cache-Control header,
+// lower cased, with their unquoted argument if any.
+func parseCacheControl(header http.Header) map[string]string {
+	directives := make(map[string]string)
+	for _, value := range header.Values("Cache-Control") {
+		for _, directive := range strings.Split(value, ",") {
+			name, argument, _ := strings.Cut(strings.TrimSpace(directive), "=")
+			if name == "" {
+				continue
+			}
+			directives[strings.ToLower(name)] = strings.Trim(argument, `"`)
+		}
+	}
+
+	return directives
+}
@@ -0,0 +1,252 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/Aloe-Corporation/client/test"
+)
+
+func TestConnector_Cache(t *testing.T) {
+	type request struct {
+		method   string
+		path     string
+		header   http.Header
+		wantBody string
+	}
+	tests := []struct {
+		name         string
+		requests     []request
+		wantUpstream int32
+		wantStats    CacheStats
+	}{
+		{
+			name: "Success case: fresh response",
+			requests: []request{
+				{path: "/max-age", wantBody: "This is data 1"},
+				{path: "/max-age", wantBody: "This is data 1"},
+				{path: "/max-age", wantBody: "This is data 1"},
+			},
+			wantUpstream: 1,
+			wantStats:    CacheStats{Hits: 2, Misses: 1},
+		},
+		{
+			name: "Success case: revalidation with ETag",
+			requests: []request{
+				{path: "/etag", wantBody: "This is data 1"},
+				{path: "/etag", wantBody: "This is data 1"},
+			},
+			wantUpstream: 2,
+			wantStats:    CacheStats{Misses: 1, Revalidations: 1},
+		},
+		{
+			name: "Success case: revalidation with Last-Modified",
+			requests: []request{
+				{path: "/last-modified", wantBody: "This is data 1"},
+				{path: "/last-modified", wantBody: "This is data 1"},
+			},
+			wantUpstream: 2,
+			wantStats:    CacheStats{Misses: 1, Revalidations: 1},
+		},
+		{
+			name: "Success case: no-store response",
+			requests: []request{
+				{path: "/no-store", wantBody: "This is data 1"},
+				{path: "/no-store", wantBody: "This is data 2"},
+			},
+			wantUpstream: 2,
+			wantStats:    CacheStats{Misses: 2},
+		},
+		{
+			name: "Success case: no-cache request",
+			requests: []request{
+				{path: "/max-age", wantBody: "This is data 1"},
+				{path: "/max-age", header: http.Header{"Cache-Control": {"no-cache"}}, wantBody: "This is data 2"},
+			},
+			wantUpstream: 2,
+			wantStats:    CacheStats{Misses: 2},
+		},
+		{
+			name: "Success case: vary",
+			requests: []request{
+				{path: "/vary", header: http.Header{"Accept-Language": {"fr"}}, wantBody: "fr"},
+				{path: "/vary", header: http.Header{"Accept-Language": {"fr"}}, wantBody: "fr"},
+				{path: "/vary", header: http.Header{"Accept-Language": {"en"}}, wantBody: "en"},
+			},
+			wantUpstream: 2,
+			wantStats:    CacheStats{Hits: 1, Misses: 2},
+		},
+		{
+			name: "Success case: caller credentials bypass the cache",
+			requests: []request{
+				{path: "/max-age", header: http.Header{"Authorization": {"Bearer tenant-a"}}, wantBody: "This is data 1"},
+				{path: "/max-age", header: http.Header{"Authorization": {"Bearer tenant-b"}}, wantBody: "This is data 2"},
+				{path: "/max-age", header: http.Header{"Cookie": {"session=b"}}, wantBody: "This is data 3"},
+				{path: "/max-age", wantBody: "This is data 4"},
+				{path: "/max-age", wantBody: "This is data 4"},
+			},
+			wantUpstream: 4,
+			wantStats:    CacheStats{Hits: 1, Misses: 1},
+		},
+		{
+			name: "Success case: invalidation by unsafe method",
+			requests: []request{
+				{path: "/max-age", wantBody: "This is data 1"},
+				{method: http.MethodPut, path: "/max-age"},
+				{path: "/max-age", wantBody: "This is data 3"},
+			},
+			wantUpstream: 3,
+			wantStats:    CacheStats{Misses: 2},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, counter := test.CacheEndpoint()
+			defer server.Close()
+
+			c := factoryConnector(Conf{URL: server.URL, PingEndpoint: "/", Cache: &CacheConf{}}, FactoryHTTPClient())
+
+			for i, r := range tt.requests {
+				method := r.method
+				if method == "" {
+					method = http.MethodGet
+				}
+
+				got, err := c.DoWithHeaderCtx(context.Background(), method, r.path, &r.header, nil, DefaultStatusRange)
+				if err != nil {
+					t.Fatalf("request %d: Connector.DoWithHeaderCtx() error = %v", i, err)
+				}
+				if method == http.MethodGet && string(got) != r.wantBody {
+					t.Errorf("request %d: Connector.DoWithHeaderCtx() = %q, want %q", i, got, r.wantBody)
+				}
+			}
+
+			if got := counter.Load(); got != tt.wantUpstream {
+				t.Errorf("upstream requests = %d, want %d", got, tt.wantUpstream)
+			}
+			if got := c.CacheStats(); got != tt.wantStats {
+				t.Errorf("Connector.CacheStats() = %+v, want %+v", got, tt.wantStats)
+			}
+		})
+	}
+}
+
+func TestConnector_Cache_Disk(t *testing.T) {
+	server, counter := test.CacheEndpoint()
+	defer server.Close()
+
+	conf := Conf{URL: server.URL, PingEndpoint: "/", Cache: &CacheConf{Store: CacheDisk, Dir: t.TempDir() + "/cache"}}
+
+	// A second connector sharing the directory is served from the disk.
+	for i := 0; i < 2; i++ {
+		c := factoryConnector(conf, FactoryHTTPClient())
+		got, err := c.SimpleGet("/max-age")
+		if err != nil {
+			t.Fatalf("Connector.SimpleGet() error = %v", err)
+		}
+		if string(got) != "This is data 1" {
+			t.Errorf("Connector.SimpleGet() = %q, want %q", got, "This is data 1")
+		}
+	}
+
+	if got := counter.Load(); got != 1 {
+		t.Errorf("upstream requests = %d, want 1", got)
+	}
+}
+
+func TestCacheEntry_freshness(t *testing.T) {
+	date := time.Date(2024, time.March, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name         string
+		header       http.Header
+		responseTime time.Time
+		now          time.Time
+		wantLifetime time.Duration
+		wantAge      time.Duration
+	}{
+		{
+			name:         "Success case: max-age over Expires",
+			header:       http.Header{"Cache-Control": {"public, max-age=300"}, "Expires": {date.Add(time.Hour).Format(http.TimeFormat)}},
+			responseTime: date,
+			now:          date.Add(time.Minute),
+			wantLifetime: 5 * time.Minute,
+			wantAge:      time.Minute,
+		},
+		{
+			name:         "Success case: Expires relative to Date",
+			header:       http.Header{"Date": {date.Format(http.TimeFormat)}, "Expires": {date.Add(time.Hour).Format(http.TimeFormat)}},
+			responseTime: date.Add(10 * time.Second),
+			now:          date.Add(10 * time.Second),
+			wantLifetime: time.Hour,
+			wantAge:      10 * time.Second,
+		},
+		{
+			name:         "Success case: Age header",
+			header:       http.Header{"Cache-Control": {"max-age=60"}, "Age": {"30"}},
+			responseTime: date,
+			now:          date.Add(10 * time.Second),
+			wantLifetime: time.Minute,
+			wantAge:      40 * time.Second,
+		},
+		{
+			name:         "Success case: heuristic freshness",
+			header:       http.Header{"Last-Modified": {date.Add(-10 * time.Hour).Format(http.TimeFormat)}},
+			responseTime: date,
+			now:          date,
+			wantLifetime: time.Hour,
+		},
+		{
+			name:         "Fail case: invalid Expires",
+			header:       http.Header{"Expires": {"0"}},
+			responseTime: date,
+			now:          date,
+			wantLifetime: 0,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := &cacheEntry{Header: tt.header, RequestTime: tt.responseTime, ResponseTime: tt.responseTime}
+
+			if got := e.freshnessLifetime(); got != tt.wantLifetime {
+				t.Errorf("cacheEntry.freshnessLifetime() = %v, want %v", got, tt.wantLifetime)
+			}
+			if got := e.age(tt.now); got != tt.wantAge {
+				t.Errorf("cacheEntry.age() = %v, want %v", got, tt.wantAge)
+			}
+		})
+	}
+}
+
+func TestMemoryCache(t *testing.T) {
+	m := NewMemoryCache(10)
+
+	m.Set("a", []byte("aaaa"))
+	m.Set("b", []byte("bbbb"))
+	if _, ok := m.Get("a"); !ok {
+		t.Fatal("MemoryCache.Get(a) should hit")
+	}
+
+	// "b" is the least recently used entry.
+	m.Set("c", []byte("cccc"))
+	if _, ok := m.Get("b"); ok {
+		t.Error("MemoryCache.Get(b) should miss after eviction")
+	}
+	if got, ok := m.Get("a"); !ok || !bytes.Equal(got, []byte("aaaa")) {
+		t.Errorf("MemoryCache.Get(a) = %q, %v", got, ok)
+	}
+
+	// Values larger than the bound are not stored.
+	m.Set("d", []byte("dddddddddddd"))
+	if _, ok := m.Get("d"); ok {
+		t.Error("MemoryCache.Get(d) should miss")
+	}
+
+	m.Delete("a")
+	if got := m.Len(); got != 1 {
+		t.Errorf("MemoryCache.Len() = %d, want 1", got)
+	}
+}
@@ -0,0 +1,162 @@
+package client
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// CacheStorage stores the serialized entries of the response cache.
+// Implementations must be goroutine safe.
+type CacheStorage interface {
+	Get(key string) ([]byte, bool)
+	Set(key string, value []byte)
+	Delete(key string)
+}
+
+// MemoryCache is an in-memory CacheStorage bounded in size.
+// The least recently used entries are evicted first.
+type MemoryCache struct {
+	maxBytes int64
+
+	mu      sync.Mutex
+	size    int64
+	order   *list.List // Front is the most recently used entry
+	entries map[string]*list.Element
+}
+
+// memoryCacheEntry is an element of MemoryCache.order.
+type memoryCacheEntry struct {
+	key   string
+	value []byte
+}
+
+// NewMemoryCache returns a MemoryCache holding at most maxBytes bytes of entries.
+func NewMemoryCache(maxBytes int64) *MemoryCache {
+	return &MemoryCache{
+		maxBytes: maxBytes,
+		order:    list.New(),
+		entries:  make(map[string]*list.Element),
+	}
+}
+
+// Get returns the entry stored at key and marks it as recently used.
+func (m *MemoryCache) Get(key string) ([]byte, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	element, ok := m.entries[key]
+	if !ok {
+		return nil, false
+	}
+	m.order.MoveToFront(element)
+
+	return element.Value.(*memoryCacheEntry).value, true
+}
+
+// Set stores value at key and evicts the least recently used entries beyond
+// the size bound. A value larger than the bound is not stored.
+func (m *MemoryCache) Set(key string, value []byte) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.remove(key)
+	if int64(len(value)) > m.maxBytes {
+		return
+	}
+
+	m.entries[key] = m.order.PushFront(&memoryCacheEntry{key: key, value: value})
+	m.size += int64(len(value))
+
+	for m.size > m.maxBytes {
+		m.remove(m.order.Back().Value.(*memoryCacheEntry).key)
+	}
+}
+
+// Delete removes the entry stored at key.
+func (m *MemoryCache) Delete(key string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.remove(key)
+}
+
+// Len returns the number of stored entries.
+func (m *MemoryCache) Len() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return len(m.entries)
+}
+
+// remove deletes the entry stored at key. The caller must hold the lock.
+func (m *MemoryCache) remove(key string) {
+	element, ok := m.entries[key]
+	if !ok {
+		return
+	}
+
+	m.order.Remove(element)
+	delete(m.entries, key)
+	m.size -= int64(len(element.Value.(*memoryCacheEntry).value))
+}
+
+// DiskCache is a CacheStorage keeping every entry in a file of a directory.
+// Entries are written atomically so that several processes can share the directory.
+type DiskCache struct {
+	dir string
+}
+
+// NewDiskCache returns a DiskCache storing its entries in dir.
+// The directory is created with the first entry if it does not exist.
+func NewDiskCache(dir string) *DiskCache {
+	return &DiskCache{dir: dir}
+}
+
+// Get returns the entry stored at key.
+func (d *DiskCache) Get(key string) ([]byte, bool) {
+	value, err := os.ReadFile(d.path(key)) // #nosec G304 -- the file name is a hash of the key
+	if err != nil {
+		return nil, false
+	}
+
+	return value, true
+}
+
+// Set stores value at key. Write failures are ignored: the entry is
+// fetched again on the next request.
+func (d *DiskCache) Set(key string, value []byte) {
+	file, err := os.CreateTemp(d.dir, ".tmp-*")
+	if errors.Is(err, fs.ErrNotExist) {
+		if err = os.MkdirAll(d.dir, 0o700); err == nil {
+			file, err = os.CreateTemp(d.dir, ".tmp-*")
+		}
+	}
+	if err != nil {
+		return
+	}
+	defer os.Remove(file.Name())
+
+	_, err = file.Write(value)
+	if errClose := file.Close(); err != nil || errClose != nil {
+		return
+	}
+
+	_ = os.Rename(file.Name(), d.path(key))
+}
+
+// Delete removes the entry stored at key.
+func (d *DiskCache) Delete(key string) {
+	_ = os.Remove(d.path(key))
+}
+
+// path returns the file of the entry stored at key.
+func (d *DiskCache) path(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return filepath.Join(d.dir, hex.EncodeToString(sum[:]))
+}
@@ -0,0 +1,198 @@
+package client
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"syscall"
+)
+
+var (
+	// ErrNotFound matches the *FailRequestError with a 404 status code.
+	ErrNotFound = errors.New("not found")
+	// ErrUnauthorized matches the *FailRequestError with a 401 status code.
+	ErrUnauthorized = errors.New("unauthorized")
+	// ErrClientError matches the *FailRequestError with a 4xx status code.
+	ErrClientError = errors.New("client error")
+	// ErrServerError matches the *FailRequestError with a 5xx status code.
+	ErrServerError = errors.New("server error")
+	// ErrTimeout matches the transport timeouts, the exceeded deadlines and the
+	// *FailRequestError with a 408 or 504 status code.
+	ErrTimeout = errors.New("timeout")
+	// ErrConnectionRefused matches the transport errors of refused connections.
+	ErrConnectionRefused = errors.New("connection refused")
+	// ErrDNS matches the transport errors of failed name resolutions.
+	ErrDNS = errors.New("DNS error")
+	// ErrTLS matches the transport errors of failed TLS handshakes and certificate verifications.
+	ErrTLS = errors.New("TLS error")
+)
+
+// IsNotFound reports if err is a *FailRequestError with a 404 status code.
+func IsNotFound(err error) bool {
+	return errors.Is(err, ErrNotFound)
+}
+
+// IsUnauthorized reports if err is a *FailRequestError with a 401 status code.
+func IsUnauthorized(err error) bool {
+	return errors.Is(err, ErrUnauthorized)
+}
+
+// IsClientError reports if err is a *FailRequestError with a 4xx status code.
+func IsClientError(err error) bool {
+	return errors.Is(err, ErrClientError)
+}
+
+// IsServerError reports if err is a *FailRequestError with a 5xx status code.
+func IsServerError(err error) bool {
+	return errors.Is(err, ErrServerError)
+}
+
+// IsTimeout reports if err is a transport timeout, an exceeded deadline or a
+// *FailRequestError with a 408 or 504 status code.
+func IsTimeout(err error) bool {
+	return errors.Is(err, ErrTimeout) || isTimeout(err)
+}
+
+// IsConnectionRefused reports if err is caused by a refused connection.
+func IsConnectionRefused(err error) bool {
+	return errors.Is(err, ErrConnectionRefused) || isConnectionRefused(err)
+}
+
+// IsDNSError reports if err is caused by a failed name resolution.
+func IsDNSError(err error) bool {
+	return errors.Is(err, ErrDNS) || isDNSError(err)
+}
+
+// IsTLSError reports if err is caused by a failed TLS handshake or certificate verification.
+func IsTLSError(err error) bool {
+	return errors.Is(err, ErrTLS) || isTLSError(err)
+}
+
+// IsRetryable reports if the request failing with err is worth a retry with
+// the default retry policy: a *FailRequestError with one of the
+// DefaultRetryableStatusCodes or a transport error accepted by IsRetryableError.
+func IsRetryable(err error) bool {
+	var failErr *FailRequestError
+	if errors.As(err, &failErr) {
+		return isRetryableStatus(failErr.Code, DefaultRetryableStatusCodes)
+	}
+
+	return IsRetryableError(err)
+}
+
+// Is reports if target is one of the sentinels matching the status code of the error.
+func (e *FailRequestError) Is(target error) bool {
+	switch target {
+	case ErrNotFound:
+		return e.Code == http.StatusNotFound
+	case ErrUnauthorized:
+		return e.Code == http.StatusUnauthorized
+	case ErrClientError:
+		return e.Code >= 400 && e.Code < 500
+	case ErrServerError:
+		return e.Code >= 500 && e.Code < 600
+	case ErrTimeout:
+		return e.Code == http.StatusRequestTimeout || e.Code == http.StatusGatewayTimeout
+	}
+
+	return false
+}
+
+// transportError is an error returned by the native client.
+// It matches the sentinels of its cause with errors.Is.
+type transportError struct {
+	err error
+}
+
+func (e *transportError) Error() string {
+	return e.err.Error()
+}
+
+func (e *transportError) Unwrap() error {
+	return e.err
+}
+
+// Is reports if target is one of the sentinels matching the cause of the error.
+func (e *transportError) Is(target error) bool {
+	switch target {
+	case ErrTimeout:
+		return isTimeout(e.err)
+	case ErrConnectionRefused:
+		return isConnectionRefused(e.err)
+	case ErrDNS:
+		return isDNSError(e.err)
+	case ErrTLS:
+		return isTLSError(e.err)
+	}
+
+	return false
+}
+
+// isTransportError reports if err was returned by the native client while
+// sending the request, rather than while preparing it or reading the response.
+func isTransportError(err error) bool {
+	var tErr *transportError
+	return errors.As(err, &tErr)
+}
+
+// isTimeout reports if err is caused by a timeout or an exceeded deadline.
+func isTimeout(err error) bool {
+	if errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
+
+// isConnectionRefused reports if err is caused by a refused connection.
+func isConnectionRefused(err error) bool {
+	return errors.Is(err, syscall.ECONNREFUSED)
+}
+
+// isDNSError reports if err is caused by a failed name resolution.
+func isDNSError(err error) bool {
+	var dnsErr *net.DNSError
+	return errors.As(err, &dnsErr)
+}
+
+// isTLSError reports if err is caused by a failed TLS handshake or certificate verification.
+func isTLSError(err error) bool {
+	var (
+		recordErr        tls.RecordHeaderError
+		alertErr         tls.AlertError
+		verificationErr  *tls.CertificateVerificationError
+		unknownAuthority x509.UnknownAuthorityError
+		hostnameErr      x509.HostnameError
+		invalidErr       x509.CertificateInvalidError
+	)
+
+	return errors.As(err, &recordErr) ||
+		errors.As(err, &alertErr) ||
+		errors.As(err, &verificationErr) ||
+		errors.As(err, &unknownAuthority) ||
+		errors.As(err, &hostnameErr) ||
+		errors.As(err, &invalidErr)
+}
+
+// isConnectionReset reports if the connection was closed before the response was received.
+func isConnectionReset(err error) bool {
+	return errors.Is(err, syscall.ECONNRESET) ||
+		errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, io.EOF)
+}
+
+// isRetryableStatus reports if code is one of codes.
+func isRetryableStatus(code int, codes []int) bool {
+	for _, c := range codes {
+		if code == c {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,201 @@
+package client
+
+import (
+	"context"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/Aloe-Corporation/client/test"
+)
+
+// classification lists the helpers matching an error.
+type classification struct {
+	notFound, unauthorized, clientError, serverError bool
+	timeout, refused, dns, tls, retryable            bool
+}
+
+// classify returns the helpers matching err.
+func classify(err error) classification {
+	return classification{
+		notFound:     IsNotFound(err),
+		unauthorized: IsUnauthorized(err),
+		clientError:  IsClientError(err),
+		serverError:  IsServerError(err),
+		timeout:      IsTimeout(err),
+		refused:      IsConnectionRefused(err),
+		dns:          IsDNSError(err),
+		tls:          IsTLSError(err),
+		retryable:    IsRetryable(err),
+	}
+}
+
+// requestError wraps err as the Connector wraps the transport errors.
+func requestError(err error) error {
+	return fmt.Errorf("fail to execute HTTP request: %w", &transportError{err: err})
+}
+
+func TestClassify(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want classification
+	}{
+		{
+			name: "Success case: not found",
+			err:  fmt.Errorf("wrapped: %w", &FailRequestError{Code: http.StatusNotFound}),
+			want: classification{notFound: true, clientError: true},
+		},
+		{
+			name: "Success case: unauthorized",
+			err:  &FailRequestError{Code: http.StatusUnauthorized},
+			want: classification{unauthorized: true, clientError: true},
+		},
+		{
+			name: "Success case: too many requests",
+			err:  &FailRequestError{Code: http.StatusTooManyRequests},
+			want: classification{clientError: true, retryable: true},
+		},
+		{
+			name: "Success case: request timeout",
+			err:  &FailRequestError{Code: http.StatusRequestTimeout},
+			want: classification{clientError: true, timeout: true},
+		},
+		{
+			name: "Success case: server error",
+			err:  &FailRequestError{Code: http.StatusInternalServerError},
+			want: classification{serverError: true, retryable: true},
+		},
+		{
+			name: "Success case: not implemented",
+			err:  &FailRequestError{Code: http.StatusNotImplemented},
+			want: classification{serverError: true},
+		},
+		{
+			name: "Success case: gateway timeout",
+			err:  &FailRequestError{Code: http.StatusGatewayTimeout},
+			want: classification{serverError: true, timeout: true, retryable: true},
+		},
+		{
+			name: "Success case: connection refused",
+			err:  requestError(&net.OpError{Op: "dial", Err: os.NewSyscallError("connect", syscall.ECONNREFUSED)}),
+			want: classification{refused: true, retryable: true},
+		},
+		{
+			name: "Success case: DNS error",
+			err:  requestError(&net.OpError{Op: "dial", Err: &net.DNSError{Err: "no such host", Name: "example.invalid", IsNotFound: true}}),
+			want: classification{dns: true},
+		},
+		{
+			name: "Success case: TLS error",
+			err:  requestError(x509.UnknownAuthorityError{}),
+			want: classification{tls: true},
+		},
+		{
+			name: "Success case: transport timeout",
+			err:  requestError(&net.DNSError{Err: "i/o timeout", IsTimeout: true}),
+			want: classification{timeout: true, dns: true, retryable: true},
+		},
+		{
+			name: "Success case: deadline exceeded",
+			err:  requestError(context.DeadlineExceeded),
+			want: classification{timeout: true},
+		},
+		{
+			name: "Success case: connection reset",
+			err:  requestError(io.ErrUnexpectedEOF),
+			want: classification{retryable: true},
+		},
+		{
+			name: "Fail case: canceled",
+			err:  requestError(context.Canceled),
+		},
+		{
+			name: "Fail case: unknown error",
+			err:  errors.New("unknown"),
+		},
+		{
+			name: "Fail case: nil error",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := classify(tt.err); got != tt.want {
+				t.Errorf("classify() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConnector_DoWithStatusCheck_Classify(t *testing.T) {
+	closed := httptest.NewServer(http.NotFoundHandler())
+	closed.Close()
+	tlsServer := httptest.NewTLSServer(http.NotFoundHandler())
+	defer tlsServer.Close()
+	slow := test.SlowEndpoint(time.Second)
+	defer slow.Close()
+	problem := test.ProblemEndpoint()
+	defer problem.Close()
+
+	tests := []struct {
+		name     string
+		url      string
+		timeout  time.Duration
+		sentinel error
+		is       func(error) bool
+	}{
+		{
+			name:     "Success case: not found",
+			url:      problem.URL,
+			sentinel: ErrNotFound,
+			is:       IsNotFound,
+		},
+		{
+			name:     "Success case: connection refused",
+			url:      closed.URL,
+			sentinel: ErrConnectionRefused,
+			is:       IsConnectionRefused,
+		},
+		{
+			name:     "Success case: TLS error",
+			url:      tlsServer.URL,
+			sentinel: ErrTLS,
+			is:       IsTLSError,
+		},
+		{
+			name:     "Success case: timeout",
+			url:      slow.URL,
+			timeout:  50 * time.Millisecond,
+			sentinel: ErrTimeout,
+			is:       IsTimeout,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := FactoryHTTPClient()
+			client.Timeout = tt.timeout
+			c := factoryConnector(Conf{URL: tt.url, PingEndpoint: "/"}, client)
+
+			req, err := http.NewRequest(http.MethodGet, tt.url+"/", nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			_, err = c.DoWithStatusCheck(req, DefaultStatusRange)
+			if !errors.Is(err, tt.sentinel) {
+				t.Errorf("errors.Is(%v, %v) = false, want true", err, tt.sentinel)
+			}
+			if !tt.is(err) {
+				t.Errorf("classification of %v = false, want true", err)
+			}
+		})
+	}
+}
@@ -1,28 +1,267 @@
 package client
 
 import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
 	"net/http"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	// RedirectFollow follows up to TransportConf.MaxRedirects redirects.
+	RedirectFollow = "follow"
+	// RedirectNone never follows redirects, the redirect response is returned as is.
+	RedirectNone = "none"
+	// RedirectSameHost follows redirects as long as they target the host of the original request.
+	RedirectSameHost = "same_host"
+
+	// defaultDialTimeout is the dial timeout when TransportConf.DialTimeout is not set.
+	defaultDialTimeout = 30 * time.Second
+	// defaultKeepAlive is the keep-alive period when TransportConf.KeepAlive is not set.
+	defaultKeepAlive = 30 * time.Second
+	// defaultMaxRedirects is the maximum number of redirects when TransportConf.MaxRedirects is not set.
+	defaultMaxRedirects = 10
 )
 
 var (
-	instanciatedClient = make(map[string]*http.Client)
+	// defaultRegistry is the registry behind ProxyFactoryHTTPClient.
+	defaultRegistry = NewClientRegistry()
+
+	// errTooManyRedirects is wrapped in the error returned when a request exceeds
+	// the maximum number of redirects.
+	errTooManyRedirects = errors.New("too many redirects")
 )
 
+// TransportConf configures the native http.Client and its http.Transport.
+// Zero values keep the net/http defaults.
+type TransportConf struct {
+	Timeout               time.Duration `yaml:"timeout"`                 // Overall timeout of a request, body reading included, none when zero
+	DialTimeout           time.Duration `yaml:"dial_timeout"`            // Timeout of the TCP connection establishment, default 30s
+	TLSHandshakeTimeout   time.Duration `yaml:"tls_handshake_timeout"`   // Timeout of the TLS handshake, default 10s
+	ResponseHeaderTimeout time.Duration `yaml:"response_header_timeout"` // Timeout waiting for the response headers, none when zero
+	KeepAlive             time.Duration `yaml:"keep_alive"`              // Period of the TCP keep-alive probes, default 30s
+	DisableKeepAlives     bool          `yaml:"disable_keep_alives"`     // Disables connection reuse between requests
+	MaxIdleConns          int           `yaml:"max_idle_conns"`          // Maximum number of idle connections, default 100
+	MaxIdleConnsPerHost   int           `yaml:"max_idle_conns_per_host"` // Maximum number of idle connections per host, default 2
+	MaxConnsPerHost       int           `yaml:"max_conns_per_host"`      // Maximum number of connections per host, unlimited when zero
+	IdleConnTimeout       time.Duration `yaml:"idle_conn_timeout"`       // Time before an idle connection is closed, default 90s
+	DisableHTTP2          bool          `yaml:"disable_http2"`           // Restricts the client to HTTP/1.1
+	RedirectPolicy        string        `yaml:"redirect_policy"`         // One of "follow", "none" or "same_host", default "follow"
+	MaxRedirects          int           `yaml:"max_redirects"`           // Maximum number of followed redirects, default 10
+}
+
 // ProxyFactoryHTTPClient creates a new client if it does not exists in
-// `instanciatedClient` map. If the client key is already defined, the
-// function returns the associated client.
+// the default ClientRegistry. If the client key is already defined, the
+// function returns the associated client. It is safe for concurrent use.
 func ProxyFactoryHTTPClient(key string) *http.Client {
-	if c, ok := instanciatedClient[key]; ok {
+	return defaultRegistry.Get(key)
+}
+
+// DefaultClientRegistry returns the registry used by ProxyFactoryHTTPClient.
+func DefaultClientRegistry() *ClientRegistry {
+	return defaultRegistry
+}
+
+// ClientRegistry is a goroutine safe registry of named http.Client.
+// Clients are built on first use, from the TransportConf given to
+// ClientRegistry.Configure for their key, or with FactoryHTTPClient.
+type ClientRegistry struct {
+	mu      sync.Mutex
+	clients map[string]*http.Client
+	confs   map[string]TransportConf
+}
+
+// NewClientRegistry returns an empty ClientRegistry.
+func NewClientRegistry() *ClientRegistry {
+	return &ClientRegistry{
+		clients: make(map[string]*http.Client),
+		confs:   make(map[string]TransportConf),
+	}
+}
+
+// Configure sets the transport configuration of the client of key.
+// An already built client of key is closed and replaced on next use.
+func (r *ClientRegistry) Configure(key string, conf TransportConf) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.confs[key] = conf
+	if c, ok := r.clients[key]; ok {
+		c.CloseIdleConnections()
+		delete(r.clients, key)
+	}
+}
+
+// Register adds an already built client under key, replacing the previous one if any.
+func (r *ClientRegistry) Register(key string, client *http.Client) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if c, ok := r.clients[key]; ok && c != client {
+		c.CloseIdleConnections()
+	}
+	r.clients[key] = client
+}
+
+// Get returns the client of key, building it if it does not exist yet.
+func (r *ClientRegistry) Get(key string) *http.Client {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if c, ok := r.clients[key]; ok {
 		return c
 	}
 
 	c := FactoryHTTPClient()
-	instanciatedClient[key] = c
+	if conf, ok := r.confs[key]; ok {
+		c = FactoryHTTPClientFromConf(conf)
+	}
+	r.clients[key] = c
 
 	return c
 }
 
+// Remove closes the idle connections of the client of key and removes it with
+// its configuration from the registry.
+func (r *ClientRegistry) Remove(key string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if c, ok := r.clients[key]; ok {
+		c.CloseIdleConnections()
+	}
+	delete(r.clients, key)
+	delete(r.confs, key)
+}
+
+// Keys returns the sorted keys of the built clients.
+func (r *ClientRegistry) Keys() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	keys := make([]string, 0, len(r.clients))
+	for key := range r.clients {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	return keys
+}
+
+// CloseIdleConnections closes the idle connections of every client.
+func (r *ClientRegistry) CloseIdleConnections() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, c := range r.clients {
+		c.CloseIdleConnections()
+	}
+}
+
+// CloseAll closes the idle connections of every client and removes them from
+// the registry. The configurations are kept so clients are built again on next use.
+func (r *ClientRegistry) CloseAll() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for key, c := range r.clients {
+		c.CloseIdleConnections()
+		delete(r.clients, key)
+	}
+}
+
 // FactoryHTTPClient returns a fresh new http.Client instance.
 func FactoryHTTPClient() *http.Client {
 	return &http.Client{}
 }
+
+// FactoryHTTPClientFromConf returns a fresh new http.Client instance with its own
+// http.Transport tuned by conf.
+func FactoryHTTPClientFromConf(conf TransportConf) *http.Client {
+	return &http.Client{
+		Transport:     FactoryHTTPTransport(conf),
+		Timeout:       conf.Timeout,
+		CheckRedirect: checkRedirect(conf),
+	}
+}
+
+// FactoryHTTPTransport returns a fresh new http.Transport tuned by conf.
+// It starts from a clone of http.DefaultTransport so zero values of conf keep
+// the net/http defaults.
+func FactoryHTTPTransport(conf TransportConf) *http.Transport {
+	var transport *http.Transport
+	if t, ok := http.DefaultTransport.(*http.Transport); ok {
+		transport = t.Clone()
+	} else {
+		transport = &http.Transport{Proxy: http.ProxyFromEnvironment, ForceAttemptHTTP2: true}
+	}
+
+	dialer := &net.Dialer{
+		Timeout:   defaultDialTimeout,
+		KeepAlive: defaultKeepAlive,
+	}
+	if conf.DialTimeout > 0 {
+		dialer.Timeout = conf.DialTimeout
+	}
+	if conf.KeepAlive != 0 {
+		dialer.KeepAlive = conf.KeepAlive
+	}
+	transport.DialContext = dialer.DialContext
+
+	if conf.TLSHandshakeTimeout > 0 {
+		transport.TLSHandshakeTimeout = conf.TLSHandshakeTimeout
+	}
+	if conf.ResponseHeaderTimeout > 0 {
+		transport.ResponseHeaderTimeout = conf.ResponseHeaderTimeout
+	}
+	if conf.MaxIdleConns > 0 {
+		transport.MaxIdleConns = conf.MaxIdleConns
+	}
+	if conf.MaxIdleConnsPerHost > 0 {
+		transport.MaxIdleConnsPerHost = conf.MaxIdleConnsPerHost
+	}
+	if conf.MaxConnsPerHost > 0 {
+		transport.MaxConnsPerHost = conf.MaxConnsPerHost
+	}
+	if conf.IdleConnTimeout > 0 {
+		transport.IdleConnTimeout = conf.IdleConnTimeout
+	}
+	transport.DisableKeepAlives = conf.DisableKeepAlives
+
+	if conf.DisableHTTP2 {
+		transport.ForceAttemptHTTP2 = false
+		transport.TLSNextProto = make(map[string]func(string, *tls.Conn) http.RoundTripper)
+	}
+
+	return transport
+}
+
+// checkRedirect returns the http.Client.CheckRedirect function implementing the
+// redirect policy of conf.
+func checkRedirect(conf TransportConf) func(req *http.Request, via []*http.Request) error {
+	maxRedirects := conf.MaxRedirects
+	if maxRedirects <= 0 {
+		maxRedirects = defaultMaxRedirects
+	}
+
+	return func(req *http.Request, via []*http.Request) error {
+		switch conf.RedirectPolicy {
+		case RedirectNone:
+			return http.ErrUseLastResponse
+		case RedirectSameHost:
+			if req.URL.Host != via[0].URL.Host {
+				return http.ErrUseLastResponse
+			}
+		}
+
+		if len(via) >= maxRedirects {
+			return fmt.Errorf("stopped after %d redirects: %w", maxRedirects, errTooManyRedirects)
+		}
+
+		return nil
+	}
+}
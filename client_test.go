@@ -1,7 +1,15 @@
 package client
 
 import (
+	"errors"
+	"net"
+	"net/http"
+	"reflect"
+	"sync"
 	"testing"
+	"time"
+
+	"github.com/Aloe-Corporation/client/test"
 )
 
 func TestProxyFactoryHTTPClient(t *testing.T) {
@@ -30,20 +38,189 @@ func TestProxyFactoryHTTPClient(t *testing.T) {
 	}
 
 	// Instanciate a client before test run
-	instanciatedClient["c0"] = FactoryHTTPClient()
+	c0 := FactoryHTTPClient()
+	DefaultClientRegistry().Register("c0", c0)
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			got := ProxyFactoryHTTPClient(tt.args.key)
 			if tt.newClient {
-				if got == instanciatedClient["c0"] {
+				if got == c0 {
 					t.Errorf("ProxyFactoryHTTPClient() = %v, should be a new client but was equal to already instanciated one.", got)
 				}
 			} else {
-				if got == nil {
-					t.Errorf("ProxyFactoryHTTPClient() = %v, client should not be nil", got)
+				if got != c0 {
+					t.Errorf("ProxyFactoryHTTPClient() = %v, should be the already instanciated client", got)
 				}
 			}
 		})
 	}
 }
+
+func TestFactoryHTTPClientFromConf(t *testing.T) {
+	conf := TransportConf{
+		Timeout:               5 * time.Second,
+		TLSHandshakeTimeout:   2 * time.Second,
+		ResponseHeaderTimeout: 3 * time.Second,
+		MaxIdleConns:          10,
+		MaxIdleConnsPerHost:   5,
+		MaxConnsPerHost:       20,
+		IdleConnTimeout:       time.Minute,
+		DisableHTTP2:          true,
+	}
+
+	got := FactoryHTTPClientFromConf(conf)
+	if got.Timeout != conf.Timeout {
+		t.Errorf("FactoryHTTPClientFromConf().Timeout = %v, want %v", got.Timeout, conf.Timeout)
+	}
+
+	transport, ok := got.Transport.(*http.Transport)
+	if !ok {
+		t.Fatalf("FactoryHTTPClientFromConf().Transport = %T, want *http.Transport", got.Transport)
+	}
+	if transport == http.DefaultTransport {
+		t.Errorf("FactoryHTTPClientFromConf().Transport should not be http.DefaultTransport")
+	}
+	if transport.TLSHandshakeTimeout != conf.TLSHandshakeTimeout ||
+		transport.ResponseHeaderTimeout != conf.ResponseHeaderTimeout ||
+		transport.MaxIdleConns != conf.MaxIdleConns ||
+		transport.MaxIdleConnsPerHost != conf.MaxIdleConnsPerHost ||
+		transport.MaxConnsPerHost != conf.MaxConnsPerHost ||
+		transport.IdleConnTimeout != conf.IdleConnTimeout {
+		t.Errorf("FactoryHTTPClientFromConf().Transport = %+v, does not match %+v", transport, conf)
+	}
+	if transport.ForceAttemptHTTP2 || transport.TLSNextProto == nil {
+		t.Errorf("FactoryHTTPClientFromConf().Transport should have HTTP/2 disabled")
+	}
+}
+
+func TestFactoryHTTPClientFromConf_Timeout(t *testing.T) {
+	server := test.SlowEndpoint(time.Second)
+	defer server.Close()
+
+	c := FactoryHTTPClientFromConf(TransportConf{ResponseHeaderTimeout: 50 * time.Millisecond})
+	resp, err := c.Get(server.URL)
+	if err == nil {
+		resp.Body.Close()
+		t.Fatalf("http.Client.Get() should fail on response header timeout")
+	}
+
+	var netErr net.Error
+	if !errors.As(err, &netErr) || !netErr.Timeout() {
+		t.Errorf("http.Client.Get() error = %v, want a timeout", err)
+	}
+}
+
+func TestFactoryHTTPClientFromConf_Redirect(t *testing.T) {
+	tests := []struct {
+		name       string
+		conf       TransportConf
+		path       string
+		target     string
+		wantStatus int
+		wantErr    bool
+	}{
+		{
+			name:       "Success case: redirects followed",
+			conf:       TransportConf{},
+			path:       "/redirect/2",
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "Success case: redirects disabled",
+			conf:       TransportConf{RedirectPolicy: RedirectNone},
+			path:       "/redirect/2",
+			wantStatus: http.StatusFound,
+		},
+		{
+			name:       "Success case: redirect to another host not followed",
+			conf:       TransportConf{RedirectPolicy: RedirectSameHost},
+			path:       "/redirect/0",
+			target:     "http://example.invalid/get",
+			wantStatus: http.StatusFound,
+		},
+		{
+			name:    "Fail case: too many redirects",
+			conf:    TransportConf{MaxRedirects: 2},
+			path:    "/redirect/5",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := test.RedirectEndpoint(tt.target)
+			defer server.Close()
+
+			resp, err := FactoryHTTPClientFromConf(tt.conf).Get(server.URL + tt.path)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("http.Client.Get() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			defer resp.Body.Close()
+
+			if resp.StatusCode != tt.wantStatus {
+				t.Errorf("http.Client.Get() status = %d, want %d", resp.StatusCode, tt.wantStatus)
+			}
+		})
+	}
+}
+
+func TestClientRegistry(t *testing.T) {
+	r := NewClientRegistry()
+	r.Configure("tuned", TransportConf{Timeout: 5 * time.Second})
+
+	tuned := r.Get("tuned")
+	if tuned.Timeout != 5*time.Second {
+		t.Errorf("ClientRegistry.Get() Timeout = %v, want the configured one", tuned.Timeout)
+	}
+	if got := r.Get("tuned"); got != tuned {
+		t.Errorf("ClientRegistry.Get() = %p, want the cached client %p", got, tuned)
+	}
+
+	bare := r.Get("bare")
+	if bare.Timeout != 0 {
+		t.Errorf("ClientRegistry.Get() Timeout = %v, want no timeout without configuration", bare.Timeout)
+	}
+
+	if got, want := r.Keys(), []string{"bare", "tuned"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("ClientRegistry.Keys() = %v, want %v", got, want)
+	}
+
+	r.Remove("bare")
+	if got, want := r.Keys(), []string{"tuned"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("ClientRegistry.Keys() after Remove() = %v, want %v", got, want)
+	}
+
+	r.CloseAll()
+	if got := r.Keys(); len(got) != 0 {
+		t.Errorf("ClientRegistry.Keys() after CloseAll() = %v, want none", got)
+	}
+	if got := r.Get("tuned"); got == tuned || got.Timeout != 5*time.Second {
+		t.Errorf("ClientRegistry.Get() after CloseAll() should build a new client from the kept configuration")
+	}
+}
+
+func TestClientRegistry_Concurrent(t *testing.T) {
+	r := NewClientRegistry()
+
+	var wg sync.WaitGroup
+	clients := make([]*http.Client, 50)
+	for i := range clients {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			clients[i] = r.Get("shared")
+			r.Keys()
+			r.CloseIdleConnections()
+		}(i)
+	}
+	wg.Wait()
+
+	for _, c := range clients {
+		if c != clients[0] {
+			t.Fatalf("ClientRegistry.Get() returned different clients for the same key")
+		}
+	}
+}
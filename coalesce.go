@@ -0,0 +1,166 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// coalescedCredentialHeaders are the request headers always distinguishing two
+// coalesced requests, so callers with different credentials never share a response.
+var coalescedCredentialHeaders = []string{"Authorization", "Cookie"}
+
+// CoalesceConf enables the coalescing of identical concurrent GET and HEAD
+// requests: while a request is in flight, the requests with the same method,
+// URL, excepted status matcher, response size limit and values of the selected
+// Headers wait for its response instead of being sent. The response body is read once and shared
+// by every waiter. Requests carrying different Authorization or Cookie headers
+// are never coalesced.
+type CoalesceConf struct {
+	Headers []string `yaml:"headers"` // Request headers distinguishing two requests, such as Accept
+}
+
+// coalescer deduplicates identical concurrent requests. It is goroutine safe.
+// A nil *coalescer sends every request.
+type coalescer struct {
+	headers []string
+
+	mu    sync.Mutex
+	calls map[string]*coalescedCall
+}
+
+// coalescedCall is a request shared by several waiters.
+type coalescedCall struct {
+	done    chan struct{}
+	cancel  context.CancelFunc
+	waiters int // Waiters still waiting, guarded by coalescer.mu
+
+	response *http.Response // Response whose body was read into body
+	body     []byte
+	err      error
+}
+
+// newCoalescer returns a coalescer configured by conf, nil if conf is nil.
+func newCoalescer(conf *CoalesceConf) *coalescer {
+	if conf == nil {
+		return nil
+	}
+
+	co := &coalescer{
+		headers: append([]string(nil), coalescedCredentialHeaders...),
+		calls:   make(map[string]*coalescedCall),
+	}
+	for _, name := range conf.Headers {
+		if name = http.CanonicalHeaderKey(name); !slices.Contains(co.headers, name) {
+			co.headers = append(co.headers, name)
+		}
+	}
+
+	return co
+}
+
+// accepts reports if req can be coalesced.
+func (co *coalescer) accepts(req *http.Request) bool {
+	return co != nil && (req.Method == http.MethodGet || req.Method == http.MethodHead)
+}
+
+// key returns the key identifying the requests sharing the response of req,
+// whose response body is limited to maxBytes.
+func (co *coalescer) key(req *http.Request, exceptedStatusCode StatusMatcher, maxBytes int64) string {
+	var key strings.Builder
+	fmt.Fprintf(&key, "%s %s %v %d", req.Method, req.URL.String(), exceptedStatusCode, maxBytes)
+	for _, name := range co.headers {
+		fmt.Fprintf(&key, "\n%s: %s", name, strings.Join(req.Header.Values(name), ","))
+	}
+
+	return key.String()
+}
+
+// do returns the response of the in flight request identical to req, sending
+// req with send if there is none. The shared request runs without the
+// cancellation of the waiters context, and is canceled once every waiter
+// gave up. The returned response body is read from memory. The requests are
+// only shared with the ones limiting their response body to the same maxBytes,
+// as the limit of the shared request applies to every waiter.
+func (co *coalescer) do(req *http.Request, exceptedStatusCode StatusMatcher, maxBytes int64, send func(*http.Request) (*http.Response, error)) (*http.Response, error) {
+	key := co.key(req, exceptedStatusCode, maxBytes)
+
+	co.mu.Lock()
+	call, ok := co.calls[key]
+	if !ok {
+		ctx, cancel := context.WithCancel(context.WithoutCancel(req.Context()))
+		call = &coalescedCall{done: make(chan struct{}), cancel: cancel}
+		co.calls[key] = call
+		go co.run(key, call, req.WithContext(ctx), send)
+	}
+	call.waiters++
+	co.mu.Unlock()
+
+	select {
+	case <-call.done:
+		if call.err != nil {
+			return nil, call.err
+		}
+		return call.share(req), nil
+
+	case <-req.Context().Done():
+		co.leave(key, call)
+		return nil, fmt.Errorf("fail to execute HTTP request: %w", req.Context().Err())
+	}
+}
+
+// run sends the shared request and reads its response body.
+func (co *coalescer) run(key string, call *coalescedCall, req *http.Request, send func(*http.Request) (*http.Response, error)) {
+	defer call.cancel()
+
+	response, err := send(req)
+	if err == nil {
+		call.body, err = io.ReadAll(response.Body)
+		response.Body.Close()
+		if err != nil {
+			err = fmt.Errorf("can't read response body : %w", err)
+		}
+	}
+	call.response, call.err = response, err
+
+	co.mu.Lock()
+	if co.calls[key] == call {
+		delete(co.calls, key)
+	}
+	co.mu.Unlock()
+
+	close(call.done)
+}
+
+// leave removes a waiter of call, canceling it if it was the last one.
+func (co *coalescer) leave(key string, call *coalescedCall) {
+	co.mu.Lock()
+	defer co.mu.Unlock()
+
+	call.waiters--
+	if call.waiters > 0 {
+		return
+	}
+
+	// The next identical request must not wait for the canceled one.
+	if co.calls[key] == call {
+		delete(co.calls, key)
+	}
+	call.cancel()
+}
+
+// share returns a copy of the shared response for the waiter req.
+func (call *coalescedCall) share(req *http.Request) *http.Response {
+	response := *call.response
+	response.Header = call.response.Header.Clone()
+	response.Body = io.NopCloser(bytes.NewReader(call.body))
+	response.ContentLength = int64(len(call.body))
+	response.Request = req
+
+	return &response
+}
@@ -0,0 +1,170 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Aloe-Corporation/client/test"
+)
+
+func TestConnector_Coalesce(t *testing.T) {
+	tests := []struct {
+		name         string
+		conf         *CoalesceConf
+		header       string // Header set by the requests, default Accept-Language
+		languages    []string
+		wantUpstream int32
+	}{
+		{
+			name:         "Success case: identical requests",
+			conf:         &CoalesceConf{},
+			languages:    []string{"fr", "fr", "en", "en", "fr"},
+			wantUpstream: 1,
+		},
+		{
+			name:         "Success case: selected header",
+			conf:         &CoalesceConf{Headers: []string{"accept-language"}},
+			languages:    []string{"fr", "fr", "en", "en", "fr"},
+			wantUpstream: 2,
+		},
+		{
+			name:         "Success case: authorization always distinguishes requests",
+			conf:         &CoalesceConf{},
+			header:       "Authorization",
+			languages:    []string{"Bearer tenant-a", "Bearer tenant-a", "Bearer tenant-b"},
+			wantUpstream: 2,
+		},
+		{
+			name:         "Success case: cookie always distinguishes requests",
+			conf:         &CoalesceConf{Headers: []string{"Accept-Language"}},
+			header:       "Cookie",
+			languages:    []string{"session=a", "session=b", "session=a"},
+			wantUpstream: 2,
+		},
+		{
+			name:         "Success case: disabled",
+			languages:    []string{"fr", "fr", "fr"},
+			wantUpstream: 3,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, counter := test.CountingSlowEndpoint(100 * time.Millisecond)
+			defer server.Close()
+
+			c := factoryConnector(Conf{URL: server.URL, PingEndpoint: "/", Coalesce: tt.conf}, FactoryHTTPClient())
+
+			var wg sync.WaitGroup
+			errs := make([]error, len(tt.languages))
+			for i, language := range tt.languages {
+				wg.Add(1)
+				go func(i int, language string) {
+					defer wg.Done()
+					name := tt.header
+					if name == "" {
+						name = "Accept-Language"
+					}
+					header := http.Header{name: {language}}
+					var got []byte
+					got, errs[i] = c.DoWithHeader(http.MethodGet, "/data", &header, nil, DefaultStatusRange)
+					if errs[i] == nil && string(got) != "This is data" {
+						errs[i] = errors.New("unexpected body " + string(got))
+					}
+				}(i, language)
+			}
+			wg.Wait()
+
+			for i, err := range errs {
+				if err != nil {
+					t.Errorf("request %d: Connector.DoWithHeader() error = %v", i, err)
+				}
+			}
+			if got := counter.Load(); got != tt.wantUpstream {
+				t.Errorf("upstream requests = %d, want %d", got, tt.wantUpstream)
+			}
+		})
+	}
+}
+
+func TestConnector_Coalesce_MaxResponseBytes(t *testing.T) {
+	server, counter := test.CountingSlowEndpoint(100 * time.Millisecond)
+	defer server.Close()
+
+	c := factoryConnector(Conf{URL: server.URL, PingEndpoint: "/", Coalesce: &CoalesceConf{}}, FactoryHTTPClient())
+
+	limits := []int64{0, 4, 0, 4}
+	var wg sync.WaitGroup
+	errs := make([]error, len(limits))
+	for i, limit := range limits {
+		wg.Add(1)
+		go func(i int, limit int64) {
+			defer wg.Done()
+			ctx := context.Background()
+			if limit > 0 {
+				ctx = WithMaxResponseBytes(ctx, limit)
+			}
+			_, errs[i] = c.DoWithHeaderCtx(ctx, http.MethodGet, "/data", nil, nil, DefaultStatusRange)
+		}(i, limit)
+	}
+	wg.Wait()
+
+	for i, err := range errs {
+		if wantErr := limits[i] > 0; errors.Is(err, ErrResponseTooLarge) != wantErr {
+			t.Errorf("request %d with limit %d: Connector.DoWithHeaderCtx() error = %v, want too large %v", i, limits[i], err, wantErr)
+		}
+	}
+	if got := counter.Load(); got != 2 {
+		t.Errorf("upstream requests = %d, want 2", got)
+	}
+}
+
+func TestConnector_Coalesce_Cancel(t *testing.T) {
+	server, counter := test.CountingSlowEndpoint(200 * time.Millisecond)
+	defer server.Close()
+
+	c := factoryConnector(Conf{URL: server.URL, PingEndpoint: "/", Coalesce: &CoalesceConf{}}, FactoryHTTPClient())
+
+	// The first waiter gives up, the second one still gets the response.
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	var wg sync.WaitGroup
+	var errCanceled, errShared error
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		_, errCanceled = c.SimpleGetCtx(ctx, "/data")
+	}()
+	go func() {
+		defer wg.Done()
+		_, errShared = c.SimpleGet("/data")
+	}()
+	wg.Wait()
+
+	if !errors.Is(errCanceled, context.DeadlineExceeded) {
+		t.Errorf("Connector.SimpleGetCtx() error = %v, want %v", errCanceled, context.DeadlineExceeded)
+	}
+	if errShared != nil {
+		t.Errorf("Connector.SimpleGet() error = %v", errShared)
+	}
+	if got := counter.Load(); got != 1 {
+		t.Errorf("upstream requests = %d, want 1", got)
+	}
+
+	// Once every waiter gave up, the next request is sent again.
+	ctx, cancel = context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if _, err := c.SimpleGetCtx(ctx, "/data"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Connector.SimpleGetCtx() error = %v, want %v", err, context.DeadlineExceeded)
+	}
+	if _, err := c.SimpleGet("/data"); err != nil {
+		t.Errorf("Connector.SimpleGet() error = %v", err)
+	}
+	if got := counter.Load(); got != 3 {
+		t.Errorf("upstream requests = %d, want 3", got)
+	}
+}
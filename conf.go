@@ -0,0 +1,341 @@
+package client
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+var (
+	// envVarPattern matches the ${VAR} and ${VAR:-default} references expanded in configuration files.
+	envVarPattern = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_]*)(?::-([^}]*))?\}`)
+
+	durationType = reflect.TypeOf(time.Duration(0))
+)
+
+// ConfError describes an invalid field of a Conf.
+type ConfError struct {
+	Field  string // Path of the field as written in YAML, such as transport.timeout
+	Reason string // Why the value is rejected
+}
+
+func (e *ConfError) Error() string {
+	return fmt.Sprintf("invalid conf field %s: %s", e.Field, e.Reason)
+}
+
+// LoadConf reads the YAML file at path and returns the validated Conf it contains.
+// References to environment variables written ${VAR} or ${VAR:-default} are
+// expanded in every value. Unknown fields are rejected.
+func LoadConf(path string) (Conf, error) {
+	var conf Conf
+
+	data, err := os.ReadFile(path) // #nosec G304 -- the path is chosen by the caller
+	if err != nil {
+		return conf, fmt.Errorf("can't read conf file: %w", err)
+	}
+
+	if err := decodeYAML(data, &conf); err != nil {
+		return conf, fmt.Errorf("can't decode conf file %s: %w", path, err)
+	}
+
+	if err := conf.Validate(); err != nil {
+		return conf, err
+	}
+
+	return conf, nil
+}
+
+// ConfFromEnv builds a validated Conf from environment variables.
+// The variable of a field is the prefix followed by the upper cased YAML
+// path of the field, separated by underscores: with the prefix "BILLING",
+// Conf.URL is read from BILLING_URL and Conf.Transport.Timeout from
+// BILLING_TRANSPORT_TIMEOUT. Durations use the time.ParseDuration format and
+// lists are comma separated. Maps can't be set from the environment.
+func ConfFromEnv(prefix string) (Conf, error) {
+	var conf Conf
+
+	if _, err := setFromEnv(reflect.ValueOf(&conf).Elem(), strings.ToUpper(prefix)); err != nil {
+		return conf, err
+	}
+
+	if err := conf.Validate(); err != nil {
+		return conf, err
+	}
+
+	return conf, nil
+}
+
+// Validate checks the consistency of the configuration. The returned error
+// joins a *ConfError for every invalid field.
+func (conf Conf) Validate() error {
+	return errors.Join(conf.validate()...)
+}
+
+// validate checks the consistency of the configuration.
+func (conf Conf) validate() []error {
+	var errs []error
+
+	switch {
+	case conf.SRV != nil && (conf.URL != "" || len(conf.URLs) > 0):
+		errs = append(errs, &ConfError{Field: "srv", Reason: "must not be set along url or urls"})
+	case conf.SRV == nil && (conf.URL != "" || len(conf.URLs) == 0):
+		errs = append(errs, validateBaseURL("url", conf.URL)...)
+	}
+	for i, u := range conf.URLs {
+		errs = append(errs, validateBaseURL(fmt.Sprintf("urls[%d]", i), u)...)
+	}
+
+	switch {
+	case conf.PingEndpoint == "":
+		errs = append(errs, &ConfError{Field: "ping_endpoint", Reason: "is required"})
+	case !strings.HasPrefix(conf.PingEndpoint, "/"):
+		errs = append(errs, &ConfError{Field: "ping_endpoint", Reason: "must start with a slash"})
+	}
+
+	errs = append(errs, validateNotNegative("max_response_bytes", conf.MaxResponseBytes)...)
+	errs = append(errs, validateNotNegative("max_error_body_bytes", conf.MaxErrorBodyBytes)...)
+	errs = append(errs, prefixConfErrors("retry", conf.Retry.validate())...)
+	errs = append(errs, prefixConfErrors("circuit_breaker", conf.CircuitBreaker.validate())...)
+	errs = append(errs, prefixConfErrors("rate_limit", conf.RateLimit.validate())...)
+	errs = append(errs, prefixConfErrors("transport", conf.Transport.validate())...)
+	errs = append(errs, prefixConfErrors("auth", conf.Auth.validate())...)
+	errs = append(errs, prefixConfErrors("balancer", conf.Balancer.validate())...)
+	errs = append(errs, prefixConfErrors("srv", conf.SRV.validate())...)
+	errs = append(errs, prefixConfErrors("cache", conf.Cache.validate())...)
+	errs = append(errs, prefixConfErrors("hedge", conf.Hedge.validate())...)
+	errs = append(errs, prefixConfErrors("monitor", conf.Monitor.validate())...)
+
+	return errs
+}
+
+// validate checks the consistency of the transport configuration.
+func (conf TransportConf) validate() []error {
+	var errs []error
+
+	errs = append(errs, validateNotNegative("timeout", conf.Timeout)...)
+	errs = append(errs, validateNotNegative("dial_timeout", conf.DialTimeout)...)
+	errs = append(errs, validateNotNegative("tls_handshake_timeout", conf.TLSHandshakeTimeout)...)
+	errs = append(errs, validateNotNegative("response_header_timeout", conf.ResponseHeaderTimeout)...)
+	errs = append(errs, validateNotNegative("idle_conn_timeout", conf.IdleConnTimeout)...)
+	errs = append(errs, validateNotNegative("max_idle_conns", conf.MaxIdleConns)...)
+	errs = append(errs, validateNotNegative("max_idle_conns_per_host", conf.MaxIdleConnsPerHost)...)
+	errs = append(errs, validateNotNegative("max_conns_per_host", conf.MaxConnsPerHost)...)
+	errs = append(errs, validateNotNegative("max_redirects", conf.MaxRedirects)...)
+
+	switch conf.RedirectPolicy {
+	case "", RedirectFollow, RedirectNone, RedirectSameHost:
+	default:
+		errs = append(errs, &ConfError{
+			Field:  "redirect_policy",
+			Reason: fmt.Sprintf("unknown policy %q, expected %s, %s or %s", conf.RedirectPolicy, RedirectFollow, RedirectNone, RedirectSameHost),
+		})
+	}
+
+	return errs
+}
+
+// validateBaseURL checks that raw is an absolute HTTP or HTTPS URL.
+func validateBaseURL(field, raw string) []error {
+	if raw == "" {
+		return []error{&ConfError{Field: field, Reason: "is required"}}
+	}
+
+	u, err := url.Parse(raw)
+	if err != nil {
+		return []error{&ConfError{Field: field, Reason: err.Error()}}
+	}
+
+	switch {
+	case u.Scheme == "":
+		return []error{&ConfError{Field: field, Reason: "missing scheme, such as https://"}}
+	case u.Scheme != "http" && u.Scheme != "https":
+		return []error{&ConfError{Field: field, Reason: fmt.Sprintf("unsupported scheme %q", u.Scheme)}}
+	case u.Host == "":
+		return []error{&ConfError{Field: field, Reason: "must be an absolute URL with a host"}}
+	case u.RawQuery != "" || u.Fragment != "":
+		return []error{&ConfError{Field: field, Reason: "must not have a query or a fragment"}}
+	}
+
+	return nil
+}
+
+// validateNotNegative returns a *ConfError if value is negative.
+func validateNotNegative[T int | int64 | float64 | time.Duration](field string, value T) []error {
+	if value < 0 {
+		return []error{&ConfError{Field: field, Reason: fmt.Sprintf("must not be negative, got %v", value)}}
+	}
+
+	return nil
+}
+
+// prefixConfErrors prefixes the field of every *ConfError of errs with prefix.
+func prefixConfErrors(prefix string, errs []error) []error {
+	for _, err := range errs {
+		var confErr *ConfError
+		if errors.As(err, &confErr) {
+			confErr.Field = prefix + "." + confErr.Field
+		}
+	}
+
+	return errs
+}
+
+// decodeYAML decodes data in out after expanding the environment variables
+// referenced in its values. Unknown fields are rejected.
+func decodeYAML(data []byte, out any) error {
+	var root yaml.Node
+	if err := yaml.Unmarshal(data, &root); err != nil {
+		return err
+	}
+	expandEnvNode(&root)
+
+	expanded, err := yaml.Marshal(&root)
+	if err != nil {
+		return err
+	}
+
+	decoder := yaml.NewDecoder(bytes.NewReader(expanded))
+	decoder.KnownFields(true)
+
+	return decoder.Decode(out)
+}
+
+// expandEnvNode expands the environment variables referenced in the scalar values of node.
+func expandEnvNode(node *yaml.Node) {
+	if node.Kind == yaml.ScalarNode && strings.Contains(node.Value, "${") {
+		node.Value = expandEnv(node.Value)
+		// The expanded value is typed again when decoded.
+		node.Tag = ""
+		node.Style = 0
+	}
+
+	for _, child := range node.Content {
+		expandEnvNode(child)
+	}
+}
+
+// expandEnv replaces the ${VAR} and ${VAR:-default} references of s by the
+// value of the environment variable VAR. The default value is used when VAR
+// is unset or empty.
+func expandEnv(s string) string {
+	return envVarPattern.ReplaceAllStringFunc(s, func(ref string) string {
+		match := envVarPattern.FindStringSubmatch(ref)
+		if value := os.Getenv(match[1]); value != "" {
+			return value
+		}
+		return match[2]
+	})
+}
+
+// setFromEnv sets the fields of v from the environment variables named after
+// name. It reports if at least one variable was found.
+func setFromEnv(v reflect.Value, name string) (bool, error) {
+	switch {
+	case v.Type() == durationType:
+		raw, ok := os.LookupEnv(name)
+		if !ok {
+			return false, nil
+		}
+		d, err := time.ParseDuration(raw)
+		if err != nil {
+			return false, fmt.Errorf("can't parse %s: %w", name, err)
+		}
+		v.SetInt(int64(d))
+		return true, nil
+
+	case v.Kind() == reflect.Pointer && v.Type().Elem().Kind() == reflect.Struct:
+		elem := reflect.New(v.Type().Elem())
+		found, err := setFromEnv(elem.Elem(), name)
+		if found {
+			v.Set(elem)
+		}
+		return found, err
+
+	case v.Kind() == reflect.Struct:
+		found := false
+		for i := 0; i < v.NumField(); i++ {
+			field := v.Type().Field(i)
+			tag := strings.Split(field.Tag.Get("yaml"), ",")[0]
+			if !field.IsExported() || tag == "-" || tag == "" {
+				continue
+			}
+
+			fieldName := strings.ToUpper(tag)
+			if name != "" {
+				fieldName = name + "_" + fieldName
+			}
+
+			fieldFound, err := setFromEnv(v.Field(i), fieldName)
+			if err != nil {
+				return false, err
+			}
+			found = found || fieldFound
+		}
+		return found, nil
+	}
+
+	raw, ok := os.LookupEnv(name)
+	if !ok {
+		return false, nil
+	}
+
+	if err := setScalar(v, raw); err != nil {
+		return false, fmt.Errorf("can't parse %s: %w", name, err)
+	}
+
+	return true, nil
+}
+
+// setScalar sets v from its string representation raw.
+// Slices are read as comma separated lists.
+func setScalar(v reflect.Value, raw string) error {
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(raw)
+
+	case reflect.Bool:
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return err
+		}
+		v.SetBool(b)
+
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i, err := strconv.ParseInt(raw, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetInt(i)
+
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(raw, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetFloat(f)
+
+	case reflect.Slice:
+		items := strings.Split(raw, ",")
+		slice := reflect.MakeSlice(v.Type(), len(items), len(items))
+		for i, item := range items {
+			if err := setScalar(slice.Index(i), strings.TrimSpace(item)); err != nil {
+				return err
+			}
+		}
+		v.Set(slice)
+
+	default:
+		return fmt.Errorf("unsupported type %s", v.Type())
+	}
+
+	return nil
+}
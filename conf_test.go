@@ -0,0 +1,366 @@
+package client
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestLoadConf(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		env     map[string]string
+		want    Conf
+		wantErr bool
+	}{
+		{
+			name: "Success case",
+			content: `
+url: https://${API_HOST}:${API_PORT:-8443}
+ping_endpoint: /ping
+max_response_bytes: 1048576
+retry:
+  max_attempts: ${RETRY_ATTEMPTS}
+  initial_backoff: 100ms
+  retryable_status_codes: [502, 503]
+transport:
+  timeout: 10s
+  disable_http2: true
+`,
+			env: map[string]string{"API_HOST": "myserver.com", "RETRY_ATTEMPTS": "3"},
+			want: Conf{
+				URL:              "https://myserver.com:8443",
+				PingEndpoint:     "/ping",
+				MaxResponseBytes: 1 << 20,
+				Retry: &RetryPolicy{
+					MaxAttempts:          3,
+					InitialBackoff:       100 * time.Millisecond,
+					RetryableStatusCodes: []int{502, 503},
+				},
+				Transport: TransportConf{
+					Timeout:      10 * time.Second,
+					DisableHTTP2: true,
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name: "Fail case: unknown field",
+			content: `
+url: https://myserver.com
+ping_endpoint: /ping
+unknown: value
+`,
+			wantErr: true,
+		},
+		{
+			name: "Fail case: invalid conf",
+			content: `
+url: myserver.com
+ping_endpoint: /ping
+`,
+			wantErr: true,
+		},
+		{
+			name:    "Fail case: invalid YAML",
+			content: `url: [`,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for key, value := range tt.env {
+				t.Setenv(key, value)
+			}
+
+			path := filepath.Join(t.TempDir(), "conf.yaml")
+			if err := os.WriteFile(path, []byte(tt.content), 0o600); err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := LoadConf(path)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("LoadConf() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("LoadConf() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadConf_MissingFile(t *testing.T) {
+	if _, err := LoadConf(filepath.Join(t.TempDir(), "missing.yaml")); err == nil {
+		t.Errorf("LoadConf() should fail on missing file")
+	}
+}
+
+func TestConfFromEnv(t *testing.T) {
+	tests := []struct {
+		name    string
+		prefix  string
+		env     map[string]string
+		want    Conf
+		wantErr bool
+	}{
+		{
+			name:   "Success case",
+			prefix: "billing",
+			env: map[string]string{
+				"BILLING_URL":                            "https://billing.com",
+				"BILLING_PING_ENDPOINT":                  "/health",
+				"BILLING_TRANSPORT_TIMEOUT":              "5s",
+				"BILLING_TRANSPORT_MAX_IDLE_CONNS":       "20",
+				"BILLING_RETRY_MAX_ATTEMPTS":             "4",
+				"BILLING_RETRY_JITTER":                   "0.5",
+				"BILLING_RETRY_RETRYABLE_STATUS_CODES":   "502, 503",
+				"BILLING_RETRY_RETRY_NON_IDEMPOTENT":     "true",
+				"BILLING_RATE_LIMIT_REQUESTS_PER_SECOND": "10",
+			},
+			want: Conf{
+				URL:          "https://billing.com",
+				PingEndpoint: "/health",
+				Retry: &RetryPolicy{
+					MaxAttempts:          4,
+					Jitter:               0.5,
+					RetryableStatusCodes: []int{502, 503},
+					RetryNonIdempotent:   true,
+				},
+				RateLimit: &RateLimitConf{RequestsPerSecond: 10},
+				Transport: TransportConf{
+					Timeout:      5 * time.Second,
+					MaxIdleConns: 20,
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name:   "Fail case: invalid duration",
+			prefix: "BILLING",
+			env: map[string]string{
+				"BILLING_URL":               "https://billing.com",
+				"BILLING_PING_ENDPOINT":     "/health",
+				"BILLING_TRANSPORT_TIMEOUT": "five seconds",
+			},
+			wantErr: true,
+		},
+		{
+			name:    "Fail case: missing URL",
+			prefix:  "BILLING",
+			env:     map[string]string{"BILLING_PING_ENDPOINT": "/health"},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for key, value := range tt.env {
+				t.Setenv(key, value)
+			}
+
+			got, err := ConfFromEnv(tt.prefix)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ConfFromEnv() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ConfFromEnv() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConf_Validate(t *testing.T) {
+	valid := Conf{URL: "https://myserver.com/api", PingEndpoint: "/ping"}
+
+	tests := []struct {
+		name       string
+		conf       func(c Conf) Conf
+		wantFields []string
+	}{
+		{
+			name:       "Success case",
+			conf:       func(c Conf) Conf { return c },
+			wantFields: nil,
+		},
+		{
+			name: "Success case: replicas without URL",
+			conf: func(c Conf) Conf {
+				c.URL = ""
+				c.URLs = []string{"https://eu.myserver.com", "https://us.myserver.com"}
+				return c
+			},
+			wantFields: nil,
+		},
+		{
+			name: "Success case: SRV record",
+			conf: func(c Conf) Conf {
+				c.URL = ""
+				c.SRV = &SRVConf{Name: "_http._tcp.myserver.service.consul"}
+				return c
+			},
+			wantFields: nil,
+		},
+		{
+			name:       "Fail case: missing URL",
+			conf:       func(c Conf) Conf { c.URL = ""; return c },
+			wantFields: []string{"url"},
+		},
+		{
+			name:       "Fail case: missing scheme",
+			conf:       func(c Conf) Conf { c.URL = "myserver.com"; return c },
+			wantFields: []string{"url"},
+		},
+		{
+			name:       "Fail case: relative URL",
+			conf:       func(c Conf) Conf { c.URL = "/api"; return c },
+			wantFields: []string{"url"},
+		},
+		{
+			name:       "Fail case: unsupported scheme",
+			conf:       func(c Conf) Conf { c.URL = "ftp://myserver.com"; return c },
+			wantFields: []string{"url"},
+		},
+		{
+			name:       "Fail case: ping endpoint without leading slash",
+			conf:       func(c Conf) Conf { c.PingEndpoint = "ping"; return c },
+			wantFields: []string{"ping_endpoint"},
+		},
+		{
+			name: "Fail case: negative values",
+			conf: func(c Conf) Conf {
+				c.Transport.Timeout = -time.Second
+				c.Transport.MaxIdleConnsPerHost = -1
+				c.MaxResponseBytes = -1
+				return c
+			},
+			wantFields: []string{"max_response_bytes", "transport.timeout", "transport.max_idle_conns_per_host"},
+		},
+		{
+			name: "Fail case: invalid nested values",
+			conf: func(c Conf) Conf {
+				c.Retry = &RetryPolicy{Jitter: 2, RetryableStatusCodes: []int{42}}
+				c.CircuitBreaker = &CircuitBreakerConf{OpenTimeout: -time.Second}
+				c.RateLimit = &RateLimitConf{Paths: map[string]RateLimit{"/search": {Burst: -1}}}
+				c.Transport.RedirectPolicy = "sometimes"
+				c.Monitor = &MonitorConf{UnhealthyThreshold: -1}
+				c.URLs = []string{"https://replica.myserver.com", "replica.myserver.com"}
+				c.Balancer = &BalancerConf{Strategy: "fastest"}
+				return c
+			},
+			wantFields: []string{
+				"urls[1]",
+				"retry.jitter",
+				"retry.retryable_status_codes",
+				"circuit_breaker.open_timeout",
+				"rate_limit.paths./search.burst",
+				"transport.redirect_policy",
+				"balancer.strategy",
+				"monitor.unhealthy_threshold",
+			},
+		},
+		{
+			name: "Fail case: incomplete authentication",
+			conf: func(c Conf) Conf {
+				c.Auth = &AuthConf{Type: AuthAPIKey, KeyName: "X-Api-Key", KeyIn: "cookie"}
+				return c
+			},
+			wantFields: []string{"auth.key_value", "auth.key_in"},
+		},
+		{
+			name: "Fail case: SRV along URL",
+			conf: func(c Conf) Conf {
+				c.SRV = &SRVConf{Scheme: "ftp", Path: "api"}
+				return c
+			},
+			wantFields: []string{"srv", "srv.name", "srv.scheme", "srv.path"},
+		},
+		{
+			name: "Fail case: invalid cache",
+			conf: func(c Conf) Conf {
+				c.Cache = &CacheConf{Store: CacheDisk, MaxBytes: -1}
+				return c
+			},
+			wantFields: []string{"cache.dir", "cache.max_bytes"},
+		},
+		{
+			name: "Fail case: invalid hedging",
+			conf: func(c Conf) Conf {
+				c.Hedge = &HedgeConf{Percentile: 1, Budget: 2}
+				return c
+			},
+			wantFields: []string{"hedge.percentile", "hedge.budget"},
+		},
+		{
+			name: "Fail case: unknown authentication",
+			conf: func(c Conf) Conf {
+				c.Auth = &AuthConf{Type: "digest"}
+				return c
+			},
+			wantFields: []string{"auth.type"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.conf(valid).Validate()
+
+			var gotFields []string
+			if err != nil {
+				for _, e := range err.(interface{ Unwrap() []error }).Unwrap() {
+					var confErr *ConfError
+					if !errors.As(e, &confErr) {
+						t.Fatalf("Conf.Validate() error = %T, want *ConfError", e)
+					}
+					gotFields = append(gotFields, confErr.Field)
+				}
+			}
+
+			if !reflect.DeepEqual(gotFields, tt.wantFields) {
+				t.Errorf("Conf.Validate() fields = %v, want %v (error: %v)", gotFields, tt.wantFields, err)
+			}
+		})
+	}
+}
+
+func TestExpandEnv(t *testing.T) {
+	t.Setenv("CLIENT_TEST_SET", "value")
+	t.Setenv("CLIENT_TEST_EMPTY", "")
+
+	tests := []struct {
+		name string
+		s    string
+		want string
+	}{
+		{
+			name: "Success case: set variable",
+			s:    "prefix-${CLIENT_TEST_SET}-suffix",
+			want: "prefix-value-suffix",
+		},
+		{
+			name: "Success case: default value",
+			s:    "${CLIENT_TEST_EMPTY:-default}",
+			want: "default",
+		},
+		{
+			name: "Success case: unset variable",
+			s:    "${CLIENT_TEST_UNSET}",
+			want: "",
+		},
+		{
+			name: "Success case: no reference",
+			s:    "$HOME and {braces}",
+			want: "$HOME and {braces}",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := expandEnv(tt.s); got != tt.want {
+				t.Errorf("expandEnv() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -11,6 +11,9 @@ import (
 const (
 	// tickInterval is interval between each request sent by the Ping() method.
 	tickInterval = 50 * time.Millisecond
+	// defaultMaxErrorBodyBytes is the maximum number of response body bytes kept in a
+	// FailRequestError when Conf.MaxErrorBodyBytes is not set.
+	defaultMaxErrorBodyBytes = 64 << 10
 )
 
 var (
@@ -21,15 +24,30 @@ var (
 	}
 )
 
-// Conf for the connector. All parameters are required.
+// Conf for the connector. PingEndpoint and one of URL, URLs or SRV are required,
+// other parameters are optional.
 type Conf struct {
-	URL          string `yaml:"url"`           // Base url of the target HTTP server such as https://myserver.com
-	PingEndpoint string `yaml:"ping_endpoint"` // Path of the ping endpoint of the target HTTP server
+	URL               string              `yaml:"url"`                  // Base url of the target HTTP server such as https://myserver.com
+	PingEndpoint      string              `yaml:"ping_endpoint"`        // Path of the ping endpoint of the target HTTP server
+	URLs              []string            `yaml:"urls"`                 // Optional replicas of the target HTTP server, URL is the first one when set
+	Balancer          *BalancerConf       `yaml:"balancer"`             // Optional strategy spreading the requests over URL and URLs
+	SRV               *SRVConf            `yaml:"srv"`                  // Optional DNS SRV record listing the replicas, replaces URL and URLs
+	Cache             *CacheConf          `yaml:"cache"`                // Optional private cache of the GET responses
+	Coalesce          *CoalesceConf       `yaml:"coalesce"`             // Optional deduplication of identical concurrent GET requests
+	Hedge             *HedgeConf          `yaml:"hedge"`                // Optional hedging of the slow GET and HEAD requests
+	Retry             *RetryPolicy        `yaml:"retry"`                // Optional retry policy, requests are sent once when nil
+	CircuitBreaker    *CircuitBreakerConf `yaml:"circuit_breaker"`      // Optional circuit breaker, disabled when nil
+	RateLimit         *RateLimitConf      `yaml:"rate_limit"`           // Optional client side rate limiting, disabled when nil
+	MaxResponseBytes  int64               `yaml:"max_response_bytes"`   // Maximum size of a response body, unlimited when zero
+	MaxErrorBodyBytes int64               `yaml:"max_error_body_bytes"` // Maximum size of FailRequestError.ResponseBody, default 64 KiB
+	Transport         TransportConf       `yaml:"transport"`            // Timeouts, connection pool and redirect policy of the native client
+	Auth              *AuthConf           `yaml:"auth"`                 // Optional authentication of the requests
+	Monitor           *MonitorConf        `yaml:"monitor"`              // Optional configuration of the background health monitor
 }
 
 // StatusCodeRange defines the range of valid status codes.
 // Status codes within the range will be considered as expected
-// codes when received from the target API.
+// codes when received from the target API. It is a StatusMatcher.
 type StatusCodeRange struct {
 	Min int // Lower bound
 	Max int // Max bound excluded
@@ -38,113 +56,293 @@ type StatusCodeRange struct {
 // Connector is a supercharged HTTP client.
 // It embeds a native http.Client so it can be used as native client.
 type Connector struct {
-	*http.Client        // Native http client
-	URL          string // Base url of the target HTTP server such as https://myserver.com
-	pingEndpoint string // Path of the ping endpoint of the target HTTP server
+	*http.Client                      // Native http client
+	URL               string          // Base url of the target HTTP server such as https://myserver.com
+	Authenticator     Authenticator   // Optional decorator adding credentials to every request
+	pingEndpoint      string          // Path of the ping endpoint of the target HTTP server
+	retry             *RetryPolicy    // Retry policy applied by Connector.DoWithStatusCheck
+	breaker           *circuitBreaker // Circuit breaker guarding the target HTTP server
+	limiter           *rateLimiter    // Client side rate limiter
+	maxBodyBytes      int64           // Maximum size of a response body, unlimited when zero
+	maxErrorBodyBytes int64           // Maximum size of FailRequestError.ResponseBody
+	monitor           *healthMonitor  // Health of the target HTTP server, updated by Connector.StartMonitor
+	balancer          *balancer       // Spreads the requests over the replicas of the target HTTP server
+	cache             *responseCache  // Private cache of the GET responses
+	coalescer         *coalescer      // Deduplicates identical concurrent GET requests
+	hedger            *hedger         // Hedges the slow GET and HEAD requests
 }
 
 // SimpleGet eases the Connector.SimpleDo use.
 // You have to specify the path.
 // The StatusCodeRange use in Connector.DoWithStatusCheck will be DefautStatusRange [200,400[.
 func (c *Connector) SimpleGet(path string) ([]byte, error) {
-	return c.SimpleDo(http.MethodGet, path, nil)
+	return c.SimpleGetCtx(context.Background(), path)
+}
+
+// SimpleGetCtx is the context aware version of Connector.SimpleGet.
+// The request is canceled when ctx is done.
+func (c *Connector) SimpleGetCtx(ctx context.Context, path string) ([]byte, error) {
+	return c.SimpleDoCtx(ctx, http.MethodGet, path, nil)
 }
 
 // SimplePost eases the Connector.SimpleDo use.
 // You have to specify the path and the request body as an io.Reader.
 // The StatusCodeRange use in Connector.DoWithStatusCheck will be DefautStatusRange [200,400[.
 func (c *Connector) SimplePost(path string, body io.Reader) ([]byte, error) {
-	return c.SimpleDo(http.MethodPost, path, body)
+	return c.SimplePostCtx(context.Background(), path, body)
+}
+
+// SimplePostCtx is the context aware version of Connector.SimplePost.
+// The request is canceled when ctx is done.
+func (c *Connector) SimplePostCtx(ctx context.Context, path string, body io.Reader) ([]byte, error) {
+	return c.SimpleDoCtx(ctx, http.MethodPost, path, body)
 }
 
 // SimplePut eases the Connector.SimpleDo use.
 // You have to specify the path and the request body as an io.Reader.
 // The StatusCodeRange use in Connector.DoWithStatusCheck will be DefautStatusRange [200,400[.
 func (c *Connector) SimplePut(path string, body io.Reader) ([]byte, error) {
-	return c.SimpleDo(http.MethodPut, path, body)
+	return c.SimplePutCtx(context.Background(), path, body)
+}
+
+// SimplePutCtx is the context aware version of Connector.SimplePut.
+// The request is canceled when ctx is done.
+func (c *Connector) SimplePutCtx(ctx context.Context, path string, body io.Reader) ([]byte, error) {
+	return c.SimpleDoCtx(ctx, http.MethodPut, path, body)
 }
 
 // SimpleDelete eases the Connector.SimpleDo use.
 // You have to specify the path and the request body as an io.Reader.
 // The StatusCodeRange use in Connector.DoWithStatusCheck will be DefautStatusRange [200,400[.
 func (c *Connector) SimpleDelete(path string, body io.Reader) ([]byte, error) {
-	return c.SimpleDo(http.MethodDelete, path, body)
+	return c.SimpleDeleteCtx(context.Background(), path, body)
+}
+
+// SimpleDeleteCtx is the context aware version of Connector.SimpleDelete.
+// The request is canceled when ctx is done.
+func (c *Connector) SimpleDeleteCtx(ctx context.Context, path string, body io.Reader) ([]byte, error) {
+	return c.SimpleDoCtx(ctx, http.MethodDelete, path, body)
 }
 
 // SimpleDo eases the Connector.SimpleDo use.
 // You have to specify the method, the path and the body as an io.Reader.
 // The StatusCodeRange use in Connector.DoWithStatusCheck will be DefautStatusRange [200,400[.
 func (c *Connector) SimpleDo(method, path string, body io.Reader) ([]byte, error) {
-	return c.DoWithHeader(method, path, nil, body, DefaultStatusRange)
+	return c.SimpleDoCtx(context.Background(), method, path, body)
+}
+
+// SimpleDoCtx is the context aware version of Connector.SimpleDo.
+// The request is canceled when ctx is done.
+func (c *Connector) SimpleDoCtx(ctx context.Context, method, path string, body io.Reader) ([]byte, error) {
+	return c.DoWithHeaderCtx(ctx, method, path, nil, body, DefaultStatusRange)
 }
 
 // DoWithHeader  eases the Connector.DoWithStatusCheck use.
-// You have to specify the method, the path, the header, the body, the excepted status range.
-// The excepted status range Min will be included and Max will be excluded.
-func (c *Connector) DoWithHeader(method, path string, header *http.Header, body io.Reader, exceptedStatusCode StatusCodeRange) ([]byte, error) {
-	req, err := http.NewRequestWithContext(context.Background(), method, c.URL+path, body)
+// You have to specify the method, the path, the header, the body, the excepted status matcher.
+// A StatusCodeRange includes Min and excludes Max.
+func (c *Connector) DoWithHeader(method, path string, header *http.Header, body io.Reader, exceptedStatusCode StatusMatcher) ([]byte, error) {
+	return c.DoWithHeaderCtx(context.Background(), method, path, header, body, exceptedStatusCode)
+}
+
+// DoWithHeaderCtx is the context aware version of Connector.DoWithHeader.
+// The request is built with ctx so cancellation and deadlines are passed
+// to the underlying http.Client.
+func (c *Connector) DoWithHeaderCtx(ctx context.Context, method, path string, header *http.Header, body io.Reader, exceptedStatusCode StatusMatcher) ([]byte, error) {
+	response, err := c.DoRequest(ctx, method, path, header, body, exceptedStatusCode)
 	if err != nil {
-		return nil, fmt.Errorf("can't create the request : %w", err)
+		return nil, err
 	}
 
-	if header != nil {
-		req.Header = *header
+	return response.Body, nil
+}
+
+// DoWithStatusCheck a HTTP request with the given request.
+// The caller should use Connector.BuildURL to build the request URL.
+// You have to provide a status matcher to validate if the request was succesfull.
+// The request context is honoured, use http.NewRequestWithContext to build it.
+func (c *Connector) DoWithStatusCheck(req *http.Request, exceptedStatusCode StatusMatcher) ([]byte, error) {
+	response, err := c.DoResponse(req, exceptedStatusCode)
+	if err != nil {
+		return nil, err
 	}
 
-	return c.DoWithStatusCheck(req, exceptedStatusCode)
+	return response.Body, nil
 }
 
-// DoWithStatusCheck a HTTP request with the given request.
-// The caller should use Connector.URL as base URL when building the request.
-// You have to provide a status code range to validate if the request was succesfull.
-func (c *Connector) DoWithStatusCheck(req *http.Request, exceptedStatusCode StatusCodeRange) ([]byte, error) {
-	response, err := c.Client.Do(req)
+// send executes req, sharing the response of an identical request in flight
+// or serving it from the response cache when possible.
+// The returned response has a status code matched by exceptedStatusCode and its
+// body, bounded by the response size limit, must be closed by the caller.
+func (c *Connector) send(req *http.Request, exceptedStatusCode StatusMatcher) (*http.Response, error) {
+	if exceptedStatusCode == nil {
+		exceptedStatusCode = DefaultStatusRange
+	}
+
+	if c.coalescer.accepts(req) {
+		return c.coalescer.do(req, exceptedStatusCode, c.maxResponseBytes(req), func(r *http.Request) (*http.Response, error) {
+			return c.sendCacheable(r, exceptedStatusCode)
+		})
+	}
+
+	return c.sendCacheable(req, exceptedStatusCode)
+}
+
+// sendCacheable executes req, serving it from the response cache when possible.
+func (c *Connector) sendCacheable(req *http.Request, exceptedStatusCode StatusMatcher) (*http.Response, error) {
+	if c.cache.accepts(req) {
+		return c.sendCached(req, exceptedStatusCode)
+	}
+
+	response, err := c.sendWithRetry(req, exceptedStatusCode)
 	if err != nil {
-		return nil, fmt.Errorf("fail to execute HTTP request: %w", err)
+		return nil, err
+	}
+	c.cache.invalidate(req, response)
+
+	return response, nil
+}
+
+// sendWithRetry executes req, retrying it according to the Connector retry policy.
+// Each attempt waits for the rate limiter and goes through the circuit breaker.
+func (c *Connector) sendWithRetry(req *http.Request, exceptedStatusCode StatusMatcher) (*http.Response, error) {
+	maxAttempts := c.retry.maxAttempts(req)
+	ctx := req.Context()
+
+	for attempt := 1; ; attempt++ {
+		r := req
+		if attempt > 1 {
+			var err error
+			if r, err = rewindRequest(req); err != nil {
+				return nil, fmt.Errorf("can't rewind request body : %w", err)
+			}
+		}
+
+		if err := c.limiter.wait(r); err != nil {
+			return nil, err
+		}
+
+		if err := c.monitor.allow(); err != nil {
+			return nil, fmt.Errorf("fail to execute HTTP request: %w", err)
+		}
+
+		generation, err := c.breaker.allow()
+		if err != nil {
+			return nil, fmt.Errorf("fail to execute HTTP request: %w", err)
+		}
+
+		response, err := c.sendHedged(r, exceptedStatusCode)
+		c.breaker.record(generation, err)
+		if err == nil {
+			if err := c.limitBody(r, response); err != nil {
+				return nil, err
+			}
+			return response, nil
+		}
+
+		if attempt >= maxAttempts || ctx.Err() != nil || !c.retry.shouldRetry(response, err) {
+			return nil, err
+		}
+
+		delay, ok := c.retry.backoff(attempt, response)
+		if !ok {
+			return nil, err
+		}
+		if deadline, ok := ctx.Deadline(); ok && time.Now().Add(delay).After(deadline) {
+			return nil, err
+		}
+
+		if errSleep := sleepCtx(ctx, delay); errSleep != nil {
+			return nil, fmt.Errorf("fail to execute HTTP request: %w", errSleep)
+		}
 	}
-	defer response.Body.Close()
+}
 
-	data, err := io.ReadAll(response.Body)
+// sendOnce authenticates and executes a single attempt of req.
+// When the status code is not matched by exceptedStatusCode, a bounded part
+// of the response body is read before closing it, and the response is returned
+// along a *FailRequestError.
+func (c *Connector) sendOnce(req *http.Request, exceptedStatusCode StatusMatcher) (*http.Response, error) {
+	start := time.Now()
+	response, err := c.doAuthenticated(req)
 	if err != nil {
-		return nil, fmt.Errorf("can't read response body : %w", err)
+		return nil, err
 	}
 
-	if response.StatusCode < exceptedStatusCode.Min || response.StatusCode >= exceptedStatusCode.Max {
-		return nil, &FailRequestError{Code: response.StatusCode, ResponseBody: data}
+	if !exceptedStatusCode.Match(response.StatusCode) {
+		defer response.Body.Close()
+
+		data, err := io.ReadAll(io.LimitReader(response.Body, c.maxErrorBytes()))
+		if err != nil {
+			return nil, fmt.Errorf("can't read response body : %w", err)
+		}
+
+		return response, newFailRequestError(req.Method, c.redactURL(req.URL), response, data, time.Since(start))
 	}
 
-	return data, nil
+	return response, nil
 }
 
 // Ping sends one ping every 50ms with timeout of t second, it ends if the ping is a success or timeout.
+// It is a shortcut of Connector.HealthCheck with the default options.
 func (c *Connector) Ping(t int) error {
-	ticker := time.NewTicker(tickInterval)
-	defer ticker.Stop()
-
-	timeout := time.After(time.Duration(t) * time.Second)
-
-	for {
-		select {
-		case <-ticker.C:
-			_, err := c.SimpleGet(c.pingEndpoint)
-			if err == nil {
-				return nil
-			}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(t)*time.Second)
+	defer cancel()
 
-		case <-timeout:
-			return fmt.Errorf("can't ping API (%s): timeout after %d s", c.URL, t)
+	result, err := c.HealthCheck(ctx, HealthCheckOptions{})
+	if err != nil {
+		if result.LastErr != nil {
+			return fmt.Errorf("can't ping API (%s): timeout after %d s, last error: %w", c.URL, t, result.LastErr)
 		}
+		return fmt.Errorf("can't ping API (%s): timeout after %d s", c.URL, t)
 	}
+
+	return nil
+}
+
+// PingCtx sends one ping every 50ms until the ping is a success or ctx is done.
+// Use context.WithTimeout to bound the wait.
+// It is a shortcut of Connector.HealthCheck with the default options.
+func (c *Connector) PingCtx(ctx context.Context) error {
+	_, err := c.HealthCheck(ctx, HealthCheckOptions{})
+	return err
 }
 
 // FactoryConnector instantiates and returns a *Connector.
 // Call *Connector.Ping() to ensure that the target API is available.
 func FactoryConnector(config Conf) *Connector {
+	return factoryConnector(config, FactoryHTTPClientFromConf(config.Transport))
+}
+
+// factoryConnector instantiates a *Connector sending its requests with client.
+func factoryConnector(config Conf, client *http.Client) *Connector {
+	var baseURL string
+	if urls := config.endpointURLs(); len(urls) > 0 {
+		baseURL = urls[0]
+	}
+
 	c := &Connector{
-		URL:          config.URL,
-		pingEndpoint: config.PingEndpoint,
-		Client:       FactoryHTTPClient(),
+		URL:               baseURL,
+		pingEndpoint:      config.PingEndpoint,
+		retry:             config.Retry,
+		breaker:           newCircuitBreaker(config.CircuitBreaker),
+		limiter:           newRateLimiter(config.RateLimit, baseURL),
+		maxBodyBytes:      config.MaxResponseBytes,
+		maxErrorBodyBytes: config.MaxErrorBodyBytes,
+		monitor:           newHealthMonitor(config.Monitor),
+		balancer:          newBalancer(config),
+		cache:             newResponseCache(config.Cache),
+		coalescer:         newCoalescer(config.Coalesce),
+		hedger:            newHedger(config.Hedge),
+		Client:            client,
 	}
 
+	c.Authenticator = newAuthenticator(config.Auth, c.Client)
+
 	return c
 }
+
+// CircuitState returns the state of the Connector circuit breaker.
+// It is always CircuitClosed when no circuit breaker is configured.
+func (c *Connector) CircuitState() CircuitState {
+	return c.breaker.State()
+}
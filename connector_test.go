@@ -2,10 +2,13 @@ package client
 
 import (
 	"bytes"
+	"context"
+	"errors"
 	"io"
 	"net/http"
 	"reflect"
 	"testing"
+	"time"
 
 	"github.com/Aloe-Corporation/client/test"
 )
@@ -428,6 +431,108 @@ func TestConnector_DoWithHeader(t *testing.T) {
 	}
 }
 
+func TestConnector_DoWithHeaderCtx(t *testing.T) {
+	type args struct {
+		timeout time.Duration
+		delay   time.Duration
+	}
+	tests := []struct {
+		name    string
+		args    args
+		want    []byte
+		wantErr error
+	}{
+		{
+			name: "Success case",
+			args: args{
+				timeout: time.Second,
+				delay:   0,
+			},
+			want:    []byte("This is data"),
+			wantErr: nil,
+		},
+		{
+			name: "Fail case: deadline exceeded before response",
+			args: args{
+				timeout: 50 * time.Millisecond,
+				delay:   time.Second,
+			},
+			want:    nil,
+			wantErr: context.DeadlineExceeded,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := test.SlowEndpoint(tt.args.delay)
+			defer server.Close()
+			c := &Connector{
+				Client: FactoryHTTPClient(),
+				URL:    server.URL,
+			}
+
+			ctx, cancel := context.WithTimeout(context.Background(), tt.args.timeout)
+			defer cancel()
+
+			got, err := c.DoWithHeaderCtx(ctx, http.MethodGet, "/get", nil, nil, DefaultStatusRange)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("Connector.DoWithHeaderCtx() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Connector.DoWithHeaderCtx() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConnector_PingCtx(t *testing.T) {
+	tests := []struct {
+		name         string
+		pingEndpoint string
+		canceled     bool
+		wantErr      bool
+	}{
+		{
+			name:         "Success case",
+			pingEndpoint: "/",
+			wantErr:      false,
+		},
+		{
+			name:         "Fail case: context already canceled",
+			pingEndpoint: "/",
+			canceled:     true,
+			wantErr:      true,
+		},
+		{
+			name:         "Fail case: wrong ping endpoint",
+			pingEndpoint: "/wrong",
+			wantErr:      true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := test.GetPingEndpoint()
+			defer server.Close()
+
+			c := &Connector{
+				Client:       FactoryHTTPClient(),
+				URL:          server.URL,
+				pingEndpoint: tt.pingEndpoint,
+			}
+
+			ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+			defer cancel()
+			if tt.canceled {
+				cancel()
+			}
+
+			if err := c.PingCtx(ctx); (err != nil) != tt.wantErr {
+				t.Errorf("Connector.PingCtx() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
 func TestConnector_Ping(t *testing.T) {
 	type fields struct {
 		Client       *http.Client
@@ -496,11 +601,40 @@ func TestFactoryConnector(t *testing.T) {
 			name: "Success case",
 			args: args{Conf{}},
 		},
+		{
+			name: "Success case: tuned transport",
+			args: args{Conf{Transport: TransportConf{
+				Timeout:             5 * time.Second,
+				TLSHandshakeTimeout: 2 * time.Second,
+				MaxIdleConnsPerHost: 8,
+				MaxConnsPerHost:     16,
+				IdleConnTimeout:     time.Minute,
+			}}},
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			if got := FactoryConnector(tt.args.config); got == nil {
-				t.Errorf("FactoryConnector() = %v, should not be nil", got)
+			got := FactoryConnector(tt.args.config)
+			if got == nil {
+				t.Fatalf("FactoryConnector() = %v, should not be nil", got)
+			}
+
+			conf := tt.args.config.Transport
+			if got.Client.Timeout != conf.Timeout {
+				t.Errorf("FactoryConnector().Client.Timeout = %v, want %v", got.Client.Timeout, conf.Timeout)
+			}
+
+			transport, ok := got.Client.Transport.(*http.Transport)
+			if !ok {
+				t.Fatalf("FactoryConnector().Client.Transport = %T, want *http.Transport", got.Client.Transport)
+			}
+			want := FactoryHTTPTransport(conf)
+			if transport == http.DefaultTransport ||
+				transport.TLSHandshakeTimeout != want.TLSHandshakeTimeout ||
+				transport.MaxIdleConnsPerHost != want.MaxIdleConnsPerHost ||
+				transport.MaxConnsPerHost != want.MaxConnsPerHost ||
+				transport.IdleConnTimeout != want.IdleConnTimeout {
+				t.Errorf("FactoryConnector().Client.Transport = %+v, does not match %+v", transport, conf)
 			}
 		})
 	}
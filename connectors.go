@@ -0,0 +1,189 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"sort"
+	"sync"
+	"time"
+)
+
+var (
+	// ErrUnknownConnector is returned by Connectors.Get for names without configuration.
+	ErrUnknownConnector = errors.New("unknown connector")
+)
+
+// Connectors is a goroutine safe registry of named Connector.
+// Each Connector is built on first use from its Conf, and the Connectors
+// whose TransportConf are equal share the same native client.
+type Connectors struct {
+	confs map[string]Conf
+
+	mu         sync.Mutex
+	connectors map[string]*Connector
+	clients    map[TransportConf]*http.Client
+}
+
+// PingResult is the result of the ping of a Connector.
+type PingResult struct {
+	Latency time.Duration // Time elapsed until the ping succeeded or failed
+	Err     error         // Error of the ping, nil if the target API is available
+}
+
+// PingReport holds the PingResult of every Connector by name.
+type PingReport map[string]PingResult
+
+// Err returns an error joining the errors of the failed pings, nil if every
+// target API is available.
+func (r PingReport) Err() error {
+	names := make([]string, 0, len(r))
+	for name := range r {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var errs []error
+	for _, name := range names {
+		if err := r[name].Err; err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", name, err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// NewConnectors returns a registry of the Connectors configured by confs.
+// Every Conf is validated, the field of every *ConfError is prefixed by the
+// name of its Connector.
+func NewConnectors(confs map[string]Conf) (*Connectors, error) {
+	names := make([]string, 0, len(confs))
+	for name := range confs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var errs []error
+	for _, name := range names {
+		errs = append(errs, prefixConfErrors(name, confs[name].validate())...)
+	}
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+
+	c := &Connectors{
+		confs:      make(map[string]Conf, len(confs)),
+		connectors: make(map[string]*Connector, len(confs)),
+		clients:    make(map[TransportConf]*http.Client),
+	}
+	for name, conf := range confs {
+		c.confs[name] = conf
+	}
+
+	return c, nil
+}
+
+// LoadConnectors reads the YAML file at path mapping Connector names to their
+// Conf, and returns the registry of these Connectors. References to environment
+// variables are expanded as in LoadConf.
+//
+//	billing:
+//	  url: https://billing.myserver.com
+//	  ping_endpoint: /ping
+//	users:
+//	  url: https://users.myserver.com
+//	  ping_endpoint: /health
+func LoadConnectors(path string) (*Connectors, error) {
+	data, err := os.ReadFile(path) // #nosec G304 -- the path is chosen by the caller
+	if err != nil {
+		return nil, fmt.Errorf("can't read connectors file: %w", err)
+	}
+
+	var confs map[string]Conf
+	if err := decodeYAML(data, &confs); err != nil {
+		return nil, fmt.Errorf("can't decode connectors file %s: %w", path, err)
+	}
+
+	return NewConnectors(confs)
+}
+
+// Get returns the Connector of name, building it on first use.
+// The returned error wraps ErrUnknownConnector if name has no configuration.
+func (c *Connectors) Get(name string) (*Connector, error) {
+	conf, ok := c.confs[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownConnector, name)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if connector, ok := c.connectors[name]; ok {
+		return connector, nil
+	}
+
+	client, ok := c.clients[conf.Transport]
+	if !ok {
+		client = FactoryHTTPClientFromConf(conf.Transport)
+		c.clients[conf.Transport] = client
+	}
+
+	connector := factoryConnector(conf, client)
+	c.connectors[name] = connector
+
+	return connector, nil
+}
+
+// Names returns the sorted names of the configured Connectors.
+func (c *Connectors) Names() []string {
+	names := make([]string, 0, len(c.confs))
+	for name := range c.confs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// PingAll pings every Connector in parallel with Connector.PingCtx and returns
+// the report once every ping succeeded or failed. Use a context with a
+// deadline to bound the wait, typically at the startup of the service.
+func (c *Connectors) PingAll(ctx context.Context) PingReport {
+	names := c.Names()
+	results := make([]PingResult, len(names))
+
+	var wg sync.WaitGroup
+	for i, name := range names {
+		wg.Add(1)
+		go func(i int, name string) {
+			defer wg.Done()
+
+			start := time.Now()
+			connector, err := c.Get(name)
+			if err == nil {
+				err = connector.PingCtx(ctx)
+			}
+			results[i] = PingResult{Latency: time.Since(start), Err: err}
+		}(i, name)
+	}
+	wg.Wait()
+
+	report := make(PingReport, len(names))
+	for i, name := range names {
+		report[name] = results[i]
+	}
+
+	return report
+}
+
+// CloseIdleConnections closes the idle connections of every native client.
+func (c *Connectors) CloseIdleConnections() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, client := range c.clients {
+		client.CloseIdleConnections()
+	}
+}
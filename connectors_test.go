@@ -0,0 +1,143 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Aloe-Corporation/client/test"
+)
+
+func TestLoadConnectors(t *testing.T) {
+	tests := []struct {
+		name      string
+		content   string
+		wantNames []string
+		wantErr   string
+	}{
+		{
+			name: "Success case",
+			content: `
+billing:
+  url: https://billing.myserver.com
+  ping_endpoint: /ping
+users:
+  url: https://users.myserver.com
+  ping_endpoint: /health
+`,
+			wantNames: []string{"billing", "users"},
+		},
+		{
+			name: "Fail case: invalid connector",
+			content: `
+billing:
+  url: billing.myserver.com
+  ping_endpoint: /ping
+`,
+			wantErr: "billing.url",
+		},
+		{
+			name: "Fail case: unknown field",
+			content: `
+billing:
+  url: https://billing.myserver.com
+  ping_endpoint: /ping
+  unknown: true
+`,
+			wantErr: "unknown",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "connectors.yaml")
+			if err := os.WriteFile(path, []byte(tt.content), 0o600); err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := LoadConnectors(path)
+			if (err != nil) != (tt.wantErr != "") {
+				t.Fatalf("LoadConnectors() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				if !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("LoadConnectors() error = %v, should contain %v", err, tt.wantErr)
+				}
+				return
+			}
+
+			if names := got.Names(); !reflect.DeepEqual(names, tt.wantNames) {
+				t.Errorf("Connectors.Names() = %v, want %v", names, tt.wantNames)
+			}
+		})
+	}
+}
+
+func TestConnectors_Get(t *testing.T) {
+	shared := TransportConf{Timeout: 5 * time.Second}
+	connectors, err := NewConnectors(map[string]Conf{
+		"billing":  {URL: "https://billing.myserver.com", PingEndpoint: "/ping", Transport: shared},
+		"users":    {URL: "https://users.myserver.com", PingEndpoint: "/ping", Transport: shared},
+		"payments": {URL: "https://payments.myserver.com", PingEndpoint: "/ping"},
+	})
+	if err != nil {
+		t.Fatalf("NewConnectors() error = %v", err)
+	}
+
+	billing, err := connectors.Get("billing")
+	if err != nil || billing.URL != "https://billing.myserver.com" {
+		t.Fatalf("Connectors.Get() = %v, %v, want the billing connector", billing, err)
+	}
+	if again, _ := connectors.Get("billing"); again != billing {
+		t.Errorf("Connectors.Get() should return the already built connector")
+	}
+
+	users, _ := connectors.Get("users")
+	payments, _ := connectors.Get("payments")
+	if users.Client != billing.Client {
+		t.Errorf("Connectors.Get() should share the client of equal transport configurations")
+	}
+	if payments.Client == billing.Client {
+		t.Errorf("Connectors.Get() should not share the client of different transport configurations")
+	}
+
+	if _, err := connectors.Get("unknown"); !errors.Is(err, ErrUnknownConnector) {
+		t.Errorf("Connectors.Get() error = %v, want %v", err, ErrUnknownConnector)
+	}
+}
+
+func TestConnectors_PingAll(t *testing.T) {
+	server := test.GetPingEndpoint()
+	defer server.Close()
+
+	connectors, err := NewConnectors(map[string]Conf{
+		"up":   {URL: server.URL, PingEndpoint: "/"},
+		"down": {URL: server.URL, PingEndpoint: "/wrong"},
+	})
+	if err != nil {
+		t.Fatalf("NewConnectors() error = %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 300*time.Millisecond)
+	defer cancel()
+
+	report := connectors.PingAll(ctx)
+	if len(report) != 2 {
+		t.Fatalf("Connectors.PingAll() = %v, want 2 results", report)
+	}
+	if report["up"].Err != nil {
+		t.Errorf("Connectors.PingAll() up error = %v, want nil", report["up"].Err)
+	}
+	if report["down"].Err == nil || report["down"].Latency <= 0 {
+		t.Errorf("Connectors.PingAll() down = %+v, want an error", report["down"])
+	}
+
+	err = report.Err()
+	if err == nil || !strings.HasPrefix(err.Error(), "down: ") {
+		t.Errorf("PingReport.Err() = %v, want the error of down", err)
+	}
+}
@@ -0,0 +1,176 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// defaultSRVRefreshInterval is the wait between two resolutions of the SRV
+	// record when SRVConf.RefreshInterval is not set.
+	defaultSRVRefreshInterval = 30 * time.Second
+	// defaultSRVResolveTimeout bounds the background resolutions of the SRV record.
+	defaultSRVResolveTimeout = 5 * time.Second
+)
+
+// SRVResolver resolves DNS SRV records. It is implemented by *net.Resolver.
+type SRVResolver interface {
+	LookupSRV(ctx context.Context, service, proto, name string) (string, []*net.SRV, error)
+}
+
+// SRVConf configures the discovery of the replicas of the target HTTP server
+// through a DNS SRV record. Every target of the record becomes an endpoint of
+// the Connector balancer: the targets of the lowest priority are used first,
+// and the random strategy, the default one, picks them according to their weight.
+// The record is resolved before the first request, then again every
+// RefreshInterval in the background, the previous targets being kept if the
+// resolution fails.
+type SRVConf struct {
+	Name            string        `yaml:"name"`             // Name of the SRV record such as _http._tcp.billing.service.consul
+	Scheme          string        `yaml:"scheme"`           // Scheme of the targets, "http" or "https", default "https"
+	Path            string        `yaml:"path"`             // Optional base path of the targets such as /api
+	RefreshInterval time.Duration `yaml:"refresh_interval"` // Wait between two resolutions, default 30s
+	Resolver        SRVResolver   `yaml:"-"`                // Resolver of the record, default net.DefaultResolver
+}
+
+// validate checks the consistency of the SRV discovery configuration.
+func (conf *SRVConf) validate() []error {
+	if conf == nil {
+		return nil
+	}
+
+	var errs []error
+	if conf.Name == "" {
+		errs = append(errs, &ConfError{Field: "name", Reason: "is required"})
+	}
+	if conf.Scheme != "" && conf.Scheme != "http" && conf.Scheme != "https" {
+		errs = append(errs, &ConfError{Field: "scheme", Reason: fmt.Sprintf("unsupported scheme %q", conf.Scheme)})
+	}
+	if conf.Path != "" && !strings.HasPrefix(conf.Path, "/") {
+		errs = append(errs, &ConfError{Field: "path", Reason: "must start with a slash"})
+	}
+	errs = append(errs, validateNotNegative("refresh_interval", conf.RefreshInterval)...)
+
+	return errs
+}
+
+// baseURL returns the base URL of the requests built by the Connector.
+// Its host is the name of the record, replaced by a target before sending.
+func (conf *SRVConf) baseURL() string {
+	scheme := conf.Scheme
+	if scheme == "" {
+		scheme = "https"
+	}
+
+	return scheme + "://" + strings.TrimSuffix(conf.Name, ".") + conf.Path
+}
+
+// srvDiscovery resolves the SRV record feeding a balancer.
+type srvDiscovery struct {
+	conf SRVConf
+
+	first      sync.Mutex // Serializes the first resolution
+	mu         sync.Mutex
+	resolvedAt time.Time // Last resolution, zero until the first success
+	resolving  bool      // A background resolution is running
+}
+
+// newSRVDiscovery returns the discovery configured by conf with its defaults set.
+func newSRVDiscovery(conf SRVConf) *srvDiscovery {
+	if conf.Scheme == "" {
+		conf.Scheme = "https"
+	}
+	if conf.RefreshInterval <= 0 {
+		conf.RefreshInterval = defaultSRVRefreshInterval
+	}
+	if conf.Resolver == nil {
+		conf.Resolver = net.DefaultResolver
+	}
+
+	return &srvDiscovery{conf: conf}
+}
+
+// refresh resolves the SRV record if it was never resolved, and starts a
+// background resolution if the last one is older than the refresh interval.
+func (b *balancer) refresh(ctx context.Context) error {
+	d := b.discovery
+	if d == nil {
+		return nil
+	}
+
+	if b.resolved() {
+		return nil
+	}
+
+	d.first.Lock()
+	defer d.first.Unlock()
+
+	if b.resolved() {
+		return nil
+	}
+
+	return b.resolve(ctx)
+}
+
+// resolved reports if the SRV record was already resolved. It starts a
+// background resolution if the last one is stale.
+func (b *balancer) resolved() bool {
+	d := b.discovery
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.resolvedAt.IsZero() {
+		return false
+	}
+
+	if b.now().Sub(d.resolvedAt) >= d.conf.RefreshInterval && !d.resolving {
+		d.resolving = true
+		go func() {
+			ctx, cancel := context.WithTimeout(context.Background(), defaultSRVResolveTimeout)
+			defer cancel()
+			_ = b.resolve(ctx)
+		}()
+	}
+
+	return true
+}
+
+// resolve looks the SRV record up and updates the endpoints of the balancer.
+func (b *balancer) resolve(ctx context.Context) error {
+	d := b.discovery
+	_, records, err := d.conf.Resolver.LookupSRV(ctx, "", "", d.conf.Name)
+	if err == nil && (len(records) == 0 || (len(records) == 1 && records[0].Target == ".")) {
+		err = errors.New("no target")
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.resolving = false
+	if err != nil {
+		if !d.resolvedAt.IsZero() {
+			d.resolvedAt = b.now()
+		}
+		return fmt.Errorf("can't resolve SRV record %s: %w", d.conf.Name, err)
+	}
+
+	urls := make([]weightedURL, 0, len(records))
+	for _, record := range records {
+		host := net.JoinHostPort(strings.TrimSuffix(record.Target, "."), strconv.Itoa(int(record.Port)))
+		urls = append(urls, weightedURL{
+			url:      d.conf.Scheme + "://" + host + d.conf.Path,
+			weight:   int(record.Weight),
+			priority: int(record.Priority),
+		})
+	}
+	b.update(urls)
+	d.resolvedAt = b.now()
+
+	return nil
+}
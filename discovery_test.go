@@ -0,0 +1,151 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/Aloe-Corporation/client/test"
+)
+
+// fakeResolver is an in-process SRVResolver answering records, or err when set.
+type fakeResolver struct {
+	records atomic.Pointer[[]*net.SRV]
+	err     error
+	lookups atomic.Int32
+}
+
+func (r *fakeResolver) LookupSRV(_ context.Context, _, _, name string) (string, []*net.SRV, error) {
+	r.lookups.Add(1)
+	if r.err != nil {
+		return "", nil, &net.DNSError{Err: r.err.Error(), Name: name}
+	}
+
+	return name, *r.records.Load(), nil
+}
+
+// srvRecord returns the SRV record of the server listening at rawURL.
+func srvRecord(t *testing.T, rawURL string, priority, weight uint16) *net.SRV {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	port, err := strconv.Atoi(u.Port())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return &net.SRV{Target: u.Hostname() + ".", Port: uint16(port), Priority: priority, Weight: weight}
+}
+
+func TestConnector_SRV(t *testing.T) {
+	primary, primaryHits := test.FlakyEndpoint(0, http.StatusOK, "")
+	defer primary.Close()
+	backup, backupHits := test.FlakyEndpoint(0, http.StatusOK, "")
+	defer backup.Close()
+
+	resolver := &fakeResolver{}
+	records := []*net.SRV{srvRecord(t, primary.URL, 10, 5), srvRecord(t, backup.URL, 20, 5)}
+	resolver.records.Store(&records)
+
+	c := factoryConnector(Conf{
+		PingEndpoint: "/",
+		SRV:          &SRVConf{Name: "_http._tcp.billing.service.consul.", Scheme: "http", Resolver: resolver},
+	}, FactoryHTTPClient())
+
+	for i := 0; i < 3; i++ {
+		if _, err := c.SimpleGet("/data"); err != nil {
+			t.Fatalf("Connector.SimpleGet() error = %v", err)
+		}
+	}
+	if got := [2]int32{primaryHits.Load(), backupHits.Load()}; got != [2]int32{3, 0} {
+		t.Errorf("hits = %v, want the lowest priority target only", got)
+	}
+
+	// The backup target is used when the primary one is down.
+	primary.Close()
+	if _, err := c.SimpleGet("/data"); err != nil {
+		t.Fatalf("Connector.SimpleGet() error = %v", err)
+	}
+	if got := backupHits.Load(); got != 1 {
+		t.Errorf("backup hits = %d, want 1", got)
+	}
+
+	if err := c.PingCtx(context.Background()); err != nil {
+		t.Errorf("Connector.PingCtx() error = %v", err)
+	}
+	if got := resolver.lookups.Load(); got != 1 {
+		t.Errorf("lookups = %d, want 1", got)
+	}
+}
+
+func TestConnector_SRV_Refresh(t *testing.T) {
+	first, firstHits := test.FlakyEndpoint(0, http.StatusOK, "")
+	defer first.Close()
+	second, secondHits := test.FlakyEndpoint(0, http.StatusOK, "")
+	defer second.Close()
+
+	resolver := &fakeResolver{}
+	records := []*net.SRV{srvRecord(t, first.URL, 0, 0)}
+	resolver.records.Store(&records)
+
+	c := factoryConnector(Conf{
+		PingEndpoint: "/",
+		SRV:          &SRVConf{Name: "billing.service.consul", Scheme: "http", RefreshInterval: time.Minute, Resolver: resolver},
+	}, FactoryHTTPClient())
+	now := time.Now()
+	c.balancer.now = func() time.Time { return now }
+
+	if _, err := c.SimpleGet("/data"); err != nil {
+		t.Fatalf("Connector.SimpleGet() error = %v", err)
+	}
+
+	records = []*net.SRV{srvRecord(t, second.URL, 0, 0)}
+	resolver.records.Store(&records)
+	now = now.Add(time.Minute)
+
+	// The stale record is resolved again in the background.
+	if _, err := c.SimpleGet("/data"); err != nil {
+		t.Fatalf("Connector.SimpleGet() error = %v", err)
+	}
+	for deadline := time.Now().Add(time.Second); secondHits.Load() == 0 && time.Now().Before(deadline); {
+		if _, err := c.SimpleGet("/data"); err != nil {
+			t.Fatalf("Connector.SimpleGet() error = %v", err)
+		}
+	}
+
+	if firstHits.Load() == 0 || secondHits.Load() == 0 {
+		t.Errorf("hits = %d, %d, want both targets used", firstHits.Load(), secondHits.Load())
+	}
+	if got := resolver.lookups.Load(); got != 2 {
+		t.Errorf("lookups = %d, want 2", got)
+	}
+}
+
+func TestConnector_SRV_ResolveError(t *testing.T) {
+	c := factoryConnector(Conf{
+		PingEndpoint: "/",
+		SRV:          &SRVConf{Name: "billing.service.consul", Resolver: &fakeResolver{err: errors.New("no such host")}},
+	}, FactoryHTTPClient())
+
+	_, err := c.SimpleGet("/data")
+	var dnsErr *net.DNSError
+	if !errors.As(err, &dnsErr) {
+		t.Errorf("Connector.SimpleGet() error = %v, want a *net.DNSError", err)
+	}
+}
+
+func TestLowestPriority(t *testing.T) {
+	endpoints := []*endpoint{{priority: 20}, {priority: 10}, {priority: 30}, {priority: 10}}
+
+	got := lowestPriority(endpoints)
+	if len(got) != 2 || got[0] != endpoints[1] || got[1] != endpoints[3] {
+		t.Errorf("lowestPriority() = %v, want the endpoints of priority 10", got)
+	}
+}
@@ -1,16 +1,151 @@
 package client
 
-import "fmt"
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+	"strings"
+	"time"
+)
 
+const (
+	// mimeProblemJSON is the media type of the RFC 9457 problem details.
+	mimeProblemJSON = "application/problem+json"
+	// maxErrorMessage is the maximum number of response body bytes reported by FailRequestError.Error.
+	maxErrorMessage = 256
+)
+
+var (
+	// requestIDHeaders are the response headers carrying the identifier given
+	// to the request by the target API, by order of preference.
+	requestIDHeaders = []string{"X-Request-Id", "X-Correlation-Id", "Request-Id", "X-Amzn-Requestid", "X-Amz-Request-Id"}
+)
+
+// FailRequestError is returned when the status code of a response is not
+// matched by the excepted status matcher.
 type FailRequestError struct {
-	Code         int
-	ResponseBody []byte
+	Code         int             // Status code of the response
+	ResponseBody []byte          // Beginning of the response body, bounded by Conf.MaxErrorBodyBytes
+	Method       string          // Method of the request
+	URL          string          // URL of the request, credentials redacted
+	Header       http.Header     // Headers of the response
+	RequestID    string          // Identifier given to the request by the target API, if any
+	Elapsed      time.Duration   // Time elapsed until the response headers were received
+	Problem      *ProblemDetails // Problem details of an application/problem+json response, nil otherwise
 }
 
+// Error returns a one line description of the failure. The response body is
+// truncated, and replaced by the title and the detail of the problem details if any.
 func (e *FailRequestError) Error() string {
-	responseBodyAsStr := string(e.ResponseBody)
-	if responseBodyAsStr == "" {
-		return fmt.Sprintf("%d fail request", e.Code)
+	var msg strings.Builder
+	fmt.Fprintf(&msg, "%d fail request", e.Code)
+
+	if e.Method != "" || e.URL != "" {
+		fmt.Fprintf(&msg, " (%s)", strings.TrimSpace(e.Method+" "+e.URL))
+	}
+	if e.RequestID != "" {
+		fmt.Fprintf(&msg, ", request id: %s", e.RequestID)
+	}
+
+	switch {
+	case e.Problem != nil && (e.Problem.Title != "" || e.Problem.Detail != ""):
+		fmt.Fprintf(&msg, ", error message: %s", strings.TrimPrefix(e.Problem.Title+": "+e.Problem.Detail, ": "))
+	case len(e.ResponseBody) > 0:
+		fmt.Fprintf(&msg, ", error message: %s", truncate(e.ResponseBody, maxErrorMessage))
+	}
+
+	return strings.TrimSuffix(msg.String(), ": ")
+}
+
+// ProblemDetails is a RFC 9457 problem details object.
+type ProblemDetails struct {
+	Type       string                     // URI identifying the problem type
+	Title      string                     // Short summary of the problem type
+	Status     int                        // Status code given by the target API
+	Detail     string                     // Explanation specific to this occurrence of the problem
+	Instance   string                     // URI identifying this occurrence of the problem
+	Extensions map[string]json.RawMessage // Other members of the problem details object
+}
+
+// UnmarshalJSON decodes a problem details object, its non standard members
+// being kept in Extensions.
+func (p *ProblemDetails) UnmarshalJSON(data []byte) error {
+	var members map[string]json.RawMessage
+	if err := json.Unmarshal(data, &members); err != nil {
+		return err
 	}
-	return fmt.Sprintf("%d fail request, error message: %s", e.Code, responseBodyAsStr)
+
+	standard := map[string]any{
+		"type":     &p.Type,
+		"title":    &p.Title,
+		"status":   &p.Status,
+		"detail":   &p.Detail,
+		"instance": &p.Instance,
+	}
+	for name, value := range members {
+		target, ok := standard[name]
+		if !ok {
+			if p.Extensions == nil {
+				p.Extensions = make(map[string]json.RawMessage)
+			}
+			p.Extensions[name] = value
+			continue
+		}
+		// RFC 9457 section 3.1: members with an invalid type are ignored.
+		_ = json.Unmarshal(value, target)
+	}
+
+	return nil
+}
+
+// newFailRequestError returns the error of a response whose status code is
+// not the excepted one. The body holds the beginning of the response body.
+func newFailRequestError(method, redactedURL string, response *http.Response, body []byte, elapsed time.Duration) *FailRequestError {
+	e := &FailRequestError{
+		Code:         response.StatusCode,
+		ResponseBody: body,
+		Method:       method,
+		URL:          redactedURL,
+		Header:       response.Header,
+		Elapsed:      elapsed,
+	}
+
+	for _, name := range requestIDHeaders {
+		if id := response.Header.Get(name); id != "" {
+			e.RequestID = id
+			break
+		}
+	}
+
+	if mediaType, _, err := mime.ParseMediaType(response.Header.Get("Content-Type")); err == nil && mediaType == mimeProblemJSON {
+		var problem ProblemDetails
+		if err := json.Unmarshal(body, &problem); err == nil {
+			e.Problem = &problem
+		}
+	}
+
+	return e
+}
+
+var (
+	// ErrResponseTooLarge matches every *ResponseTooLargeError with errors.Is.
+	ErrResponseTooLarge = errors.New("response body too large")
+)
+
+// ResponseTooLargeError is returned when a response body exceeds the
+// configured maximum size.
+type ResponseTooLargeError struct {
+	Limit int64 // Maximum size of the response body in bytes
+	Read  int64 // Number of bytes read before giving up
+}
+
+func (e *ResponseTooLargeError) Error() string {
+	return fmt.Sprintf("%s: read %d bytes, limit is %d bytes", ErrResponseTooLarge.Error(), e.Read, e.Limit)
+}
+
+// Is reports if target is ErrResponseTooLarge.
+func (e *ResponseTooLargeError) Is(target error) bool {
+	return target == ErrResponseTooLarge
 }
@@ -1,11 +1,25 @@
 package client
 
-import "testing"
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Aloe-Corporation/client/test"
+)
 
 func TestFailRequestError_Error(t *testing.T) {
 	type fields struct {
 		Code         int
 		ResponseBody []byte
+		Method       string
+		URL          string
+		RequestID    string
+		Problem      *ProblemDetails
 	}
 
 	tests := []struct {
@@ -29,6 +43,34 @@ func TestFailRequestError_Error(t *testing.T) {
 			},
 			want: "400 fail request, error message: error message",
 		},
+		{
+			name: "Success case with request context",
+			fields: fields{
+				Code:         404,
+				ResponseBody: []byte("not found"),
+				Method:       http.MethodGet,
+				URL:          "https://myserver.com/users/42",
+				RequestID:    "req-42",
+			},
+			want: "404 fail request (GET https://myserver.com/users/42), request id: req-42, error message: not found",
+		},
+		{
+			name: "Success case with problem details",
+			fields: fields{
+				Code:         404,
+				ResponseBody: []byte(`{"title":"Not Found","detail":"user 42 does not exist"}`),
+				Problem:      &ProblemDetails{Title: "Not Found", Detail: "user 42 does not exist"},
+			},
+			want: "404 fail request, error message: Not Found: user 42 does not exist",
+		},
+		{
+			name: "Success case with truncated response body",
+			fields: fields{
+				Code:         500,
+				ResponseBody: []byte(strings.Repeat("a", maxErrorMessage+10)),
+			},
+			want: "500 fail request, error message: " + strings.Repeat("a", maxErrorMessage) + "...",
+		},
 	}
 
 	for _, tt := range tests {
@@ -36,6 +78,10 @@ func TestFailRequestError_Error(t *testing.T) {
 			e := &FailRequestError{
 				Code:         tt.fields.Code,
 				ResponseBody: tt.fields.ResponseBody,
+				Method:       tt.fields.Method,
+				URL:          tt.fields.URL,
+				RequestID:    tt.fields.RequestID,
+				Problem:      tt.fields.Problem,
 			}
 			if got := e.Error(); got != tt.want {
 				t.Errorf("FailRequestError.Error() = %v, want %v", got, tt.want)
@@ -43,3 +89,57 @@ func TestFailRequestError_Error(t *testing.T) {
 		})
 	}
 }
+
+func TestResponseTooLargeError_Error(t *testing.T) {
+	e := &ResponseTooLargeError{Limit: 1024, Read: 1025}
+	want := "response body too large: read 1025 bytes, limit is 1024 bytes"
+	if got := e.Error(); got != want {
+		t.Errorf("ResponseTooLargeError.Error() = %v, want %v", got, want)
+	}
+}
+
+func TestConnector_FailRequestError(t *testing.T) {
+	server := test.ProblemEndpoint()
+	defer server.Close()
+
+	c := &Connector{
+		Client:        FactoryHTTPClient(),
+		URL:           server.URL,
+		Authenticator: &APIKeyAuth{Name: "key", Value: "secret", In: APIKeyInQuery},
+	}
+
+	_, err := c.SimpleGet("/users/42?token=secret&page=1")
+
+	var failErr *FailRequestError
+	if !errors.As(err, &failErr) {
+		t.Fatalf("Connector.SimpleGet() error = %v, want a *FailRequestError", err)
+	}
+
+	if failErr.Method != http.MethodGet {
+		t.Errorf("FailRequestError.Method = %v, want %v", failErr.Method, http.MethodGet)
+	}
+	if want := server.URL + "/users/42?page=1&token=REDACTED"; failErr.URL != want {
+		t.Errorf("FailRequestError.URL = %v, want %v", failErr.URL, want)
+	}
+	if failErr.RequestID != "req-42" || failErr.Header.Get("X-Request-Id") != "req-42" {
+		t.Errorf("FailRequestError.RequestID = %v, want req-42", failErr.RequestID)
+	}
+	if failErr.Elapsed <= 0 || failErr.Elapsed > time.Second {
+		t.Errorf("FailRequestError.Elapsed = %v", failErr.Elapsed)
+	}
+
+	want := &ProblemDetails{
+		Type:       "https://example.com/probs/not-found",
+		Title:      "Not Found",
+		Status:     404,
+		Detail:     "user 42 does not exist",
+		Instance:   "/users/42",
+		Extensions: map[string]json.RawMessage{"user_id": json.RawMessage("42")},
+	}
+	if !reflect.DeepEqual(failErr.Problem, want) {
+		t.Errorf("FailRequestError.Problem = %+v, want %+v", failErr.Problem, want)
+	}
+	if strings.Contains(err.Error(), "secret") {
+		t.Errorf("Connector.SimpleGet() error = %v, should not contain credentials", err)
+	}
+}
@@ -1,3 +1,5 @@
 module github.com/Aloe-Corporation/client
 
 go 1.21.3
+
+require gopkg.in/yaml.v3 v3.0.1
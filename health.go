@@ -0,0 +1,147 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// HealthCheckOptions configures Connector.HealthCheck.
+// Zero values select the defaults.
+type HealthCheckOptions struct {
+	Endpoint       string                  // Path of the checked endpoint, default Conf.PingEndpoint
+	Interval       time.Duration           // Wait between the first two attempts, default 50ms
+	MaxInterval    time.Duration           // Upper bound of the wait between two attempts, default Interval
+	Multiplier     float64                 // Growth factor of the wait between two attempts, default 1
+	AttemptTimeout time.Duration           // Timeout of a single attempt, bounded by the context only when zero
+	MaxAttempts    int                     // Maximum number of attempts, unlimited when zero
+	ExpectedStatus StatusMatcher           // Status codes of a healthy response, default DefaultStatusRange
+	BodyMatcher    func(body []byte) error // Optional check of the response body of a healthy response
+}
+
+// HealthCheckResult describes the outcome of Connector.HealthCheck.
+type HealthCheckResult struct {
+	Healthy    bool          // The last attempt succeeded
+	Attempts   int           // Number of attempts sent
+	Latency    time.Duration // Latency of the last attempt
+	Elapsed    time.Duration // Time elapsed since the first attempt
+	StatusCode int           // Status code of the last response, zero if none was received
+	LastErr    error         // Error of the last attempt, nil if healthy
+}
+
+// BodyContains returns a HealthCheckOptions.BodyMatcher checking that the
+// response body contains s.
+func BodyContains(s string) func(body []byte) error {
+	return func(body []byte) error {
+		if !bytes.Contains(body, []byte(s)) {
+			return fmt.Errorf("response body does not contain %q", s)
+		}
+		return nil
+	}
+}
+
+// HealthCheck sends GET requests to the health check endpoint until one of them
+// succeeds, MaxAttempts is reached or ctx is done. Health checks bypass the
+// retry policy, the circuit breaker and the rate limiter of the Connector, but
+// are spread over its endpoints like the other requests.
+// The result is returned in any case. The error is nil if the target API is
+// healthy and wraps the last attempt error otherwise.
+func (c *Connector) HealthCheck(ctx context.Context, opts HealthCheckOptions) (HealthCheckResult, error) {
+	opts = opts.withDefaults(c.pingEndpoint)
+
+	var result HealthCheckResult
+	start := time.Now()
+	interval := opts.Interval
+
+	for {
+		attemptStart := time.Now()
+		result.Attempts++
+		result.StatusCode, result.LastErr = c.probe(ctx, opts)
+		result.Latency = time.Since(attemptStart)
+		result.Elapsed = time.Since(start)
+
+		if result.LastErr == nil {
+			result.Healthy = true
+			return result, nil
+		}
+
+		if opts.MaxAttempts > 0 && result.Attempts >= opts.MaxAttempts {
+			return result, fmt.Errorf("can't ping API (%s): %d attempts failed, last error: %w",
+				c.URL, result.Attempts, result.LastErr)
+		}
+
+		if err := sleepCtx(ctx, interval); err != nil {
+			result.Elapsed = time.Since(start)
+			return result, fmt.Errorf("can't ping API (%s): %w after %d attempts, last error: %w",
+				c.URL, err, result.Attempts, result.LastErr)
+		}
+
+		interval = time.Duration(float64(interval) * opts.Multiplier)
+		if interval > opts.MaxInterval {
+			interval = opts.MaxInterval
+		}
+	}
+}
+
+// probe sends a single health check request and returns the status code of
+// the response, zero if none was received.
+func (c *Connector) probe(ctx context.Context, opts HealthCheckOptions) (int, error) {
+	if opts.AttemptTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, opts.AttemptTimeout)
+		defer cancel()
+	}
+
+	req, err := c.newRequest(ctx, http.MethodGet, opts.Endpoint, nil)
+	if err != nil {
+		return 0, err
+	}
+
+	response, err := c.sendBalanced(req, opts.ExpectedStatus)
+	if err != nil {
+		if response != nil {
+			return response.StatusCode, err
+		}
+		return 0, err
+	}
+	defer response.Body.Close()
+
+	if opts.BodyMatcher == nil {
+		return response.StatusCode, nil
+	}
+
+	body, err := io.ReadAll(io.LimitReader(response.Body, c.maxErrorBytes()))
+	if err != nil {
+		return response.StatusCode, fmt.Errorf("can't read response body : %w", err)
+	}
+
+	if err := opts.BodyMatcher(body); err != nil {
+		return response.StatusCode, fmt.Errorf("unexpected health check response: %w", err)
+	}
+
+	return response.StatusCode, nil
+}
+
+// withDefaults returns a copy of opts with the defaults set.
+func (opts HealthCheckOptions) withDefaults(pingEndpoint string) HealthCheckOptions {
+	if opts.Endpoint == "" {
+		opts.Endpoint = pingEndpoint
+	}
+	if opts.Interval <= 0 {
+		opts.Interval = tickInterval
+	}
+	if opts.MaxInterval < opts.Interval {
+		opts.MaxInterval = opts.Interval
+	}
+	if opts.Multiplier < 1 {
+		opts.Multiplier = 1
+	}
+	if opts.ExpectedStatus == nil || opts.ExpectedStatus == (StatusCodeRange{}) {
+		opts.ExpectedStatus = DefaultStatusRange
+	}
+
+	return opts
+}
@@ -0,0 +1,121 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/Aloe-Corporation/client/test"
+)
+
+func TestConnector_HealthCheck(t *testing.T) {
+	tests := []struct {
+		name           string
+		failures       int
+		opts           HealthCheckOptions
+		wantErr        bool
+		wantAttempts   int
+		wantStatusCode int
+	}{
+		{
+			name:           "Success case: first attempt",
+			opts:           HealthCheckOptions{MaxAttempts: 3},
+			wantAttempts:   1,
+			wantStatusCode: http.StatusOK,
+		},
+		{
+			name:           "Success case: after failures",
+			failures:       2,
+			opts:           HealthCheckOptions{Interval: time.Millisecond, MaxAttempts: 5},
+			wantAttempts:   3,
+			wantStatusCode: http.StatusOK,
+		},
+		{
+			name:           "Success case: expected status",
+			failures:       1,
+			opts:           HealthCheckOptions{MaxAttempts: 1, ExpectedStatus: StatusCodeRange{Min: 503, Max: 504}},
+			wantAttempts:   1,
+			wantStatusCode: http.StatusServiceUnavailable,
+		},
+		{
+			name:           "Success case: body matcher",
+			opts:           HealthCheckOptions{MaxAttempts: 1, BodyMatcher: BodyContains("data")},
+			wantAttempts:   1,
+			wantStatusCode: http.StatusOK,
+		},
+		{
+			name:           "Fail case: body matcher",
+			opts:           HealthCheckOptions{MaxAttempts: 2, Interval: time.Millisecond, BodyMatcher: BodyContains("ok")},
+			wantErr:        true,
+			wantAttempts:   2,
+			wantStatusCode: http.StatusOK,
+		},
+		{
+			name:           "Fail case: max attempts",
+			failures:       5,
+			opts:           HealthCheckOptions{Interval: time.Millisecond, Multiplier: 2, MaxAttempts: 3},
+			wantErr:        true,
+			wantAttempts:   3,
+			wantStatusCode: http.StatusServiceUnavailable,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, _ := test.FlakyEndpoint(tt.failures, http.StatusServiceUnavailable, "")
+			defer server.Close()
+
+			c := &Connector{
+				Client:       FactoryHTTPClient(),
+				URL:          server.URL,
+				pingEndpoint: "/",
+			}
+
+			got, err := c.HealthCheck(context.Background(), tt.opts)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Connector.HealthCheck() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got.Healthy == tt.wantErr {
+				t.Errorf("Connector.HealthCheck() Healthy = %v, want %v", got.Healthy, !tt.wantErr)
+			}
+			if got.Attempts != tt.wantAttempts {
+				t.Errorf("Connector.HealthCheck() Attempts = %d, want %d", got.Attempts, tt.wantAttempts)
+			}
+			if got.StatusCode != tt.wantStatusCode {
+				t.Errorf("Connector.HealthCheck() StatusCode = %d, want %d", got.StatusCode, tt.wantStatusCode)
+			}
+			if tt.wantErr && (got.LastErr == nil || !errors.Is(err, got.LastErr)) {
+				t.Errorf("Connector.HealthCheck() error = %v, should wrap last error %v", err, got.LastErr)
+			}
+		})
+	}
+}
+
+func TestConnector_HealthCheck_Timeouts(t *testing.T) {
+	server := test.SlowEndpoint(200 * time.Millisecond)
+	defer server.Close()
+
+	c := &Connector{
+		Client:       FactoryHTTPClient(),
+		URL:          server.URL,
+		pingEndpoint: "/",
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 150*time.Millisecond)
+	defer cancel()
+
+	got, err := c.HealthCheck(ctx, HealthCheckOptions{AttemptTimeout: 20 * time.Millisecond, Interval: 10 * time.Millisecond})
+	if err == nil {
+		t.Fatal("Connector.HealthCheck() should fail")
+	}
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Connector.HealthCheck() error = %v, should wrap context.DeadlineExceeded", err)
+	}
+	if got.Attempts < 2 {
+		t.Errorf("Connector.HealthCheck() Attempts = %d, want at least 2", got.Attempts)
+	}
+	if got.Latency >= 100*time.Millisecond {
+		t.Errorf("Connector.HealthCheck() Latency = %v, should be bounded by the attempt timeout", got.Latency)
+	}
+}
@@ -0,0 +1,291 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	// defaultHedgePercentile is the percentile of the observed latencies used as
+	// hedging delay when HedgeConf.Percentile is not set.
+	defaultHedgePercentile = 0.95
+	// defaultHedgeBudget is the maximum ratio of hedged attempts to requests
+	// when HedgeConf.Budget is not set.
+	defaultHedgeBudget = 0.1
+	// hedgeWindow is the number of latencies kept to compute the hedging delay.
+	hedgeWindow = 128
+	// minHedgeSamples is the number of latencies to observe before the
+	// percentile is used as hedging delay.
+	minHedgeSamples = 20
+)
+
+// HedgeConf enables hedged requests: when an attempt of a GET or HEAD request
+// has not answered within the hedging delay, a second attempt is sent and the
+// first successful response wins, the other attempt being canceled.
+// The hedging delay is Delay if set, otherwise the Percentile of the latencies
+// observed once enough requests completed. Hedged attempts are limited to a
+// ratio Budget of the requests.
+type HedgeConf struct {
+	Delay      time.Duration `yaml:"delay"`      // Fixed hedging delay, the observed percentile latency when zero
+	Percentile float64       `yaml:"percentile"` // Percentile of the observed latencies used as delay, default 0.95
+	Budget     float64       `yaml:"budget"`     // Maximum ratio of hedged attempts to requests, default 0.1
+}
+
+// validate checks the consistency of the hedging configuration.
+func (conf *HedgeConf) validate() []error {
+	if conf == nil {
+		return nil
+	}
+
+	var errs []error
+	errs = append(errs, validateNotNegative("delay", conf.Delay)...)
+	if conf.Percentile < 0 || conf.Percentile >= 1 {
+		errs = append(errs, &ConfError{Field: "percentile", Reason: fmt.Sprintf("must be within [0,1[, got %v", conf.Percentile)})
+	}
+	if conf.Budget < 0 || conf.Budget > 1 {
+		errs = append(errs, &ConfError{Field: "budget", Reason: fmt.Sprintf("must be within [0,1], got %v", conf.Budget)})
+	}
+
+	return errs
+}
+
+// HedgeStats counts the hedged requests of a Connector.
+type HedgeStats struct {
+	Requests int64 // Requests eligible to hedging
+	Hedges   int64 // Hedged attempts sent
+	Wins     int64 // Hedged attempts whose response won
+}
+
+// hedger decides when to hedge requests. It is goroutine safe.
+// A nil *hedger never hedges.
+type hedger struct {
+	conf HedgeConf
+
+	mu        sync.Mutex
+	latencies []time.Duration // Ring buffer of the last latencies
+	next      int             // Next position in latencies
+	stats     HedgeStats
+}
+
+// newHedger returns a hedger configured by conf, nil if conf is nil.
+func newHedger(conf *HedgeConf) *hedger {
+	if conf == nil {
+		return nil
+	}
+
+	h := &hedger{conf: *conf}
+	if h.conf.Percentile <= 0 {
+		h.conf.Percentile = defaultHedgePercentile
+	}
+	if h.conf.Budget <= 0 {
+		h.conf.Budget = defaultHedgeBudget
+	}
+
+	return h
+}
+
+// HedgeStats returns the statistics of the hedged requests.
+// They are always zero when hedging is not configured.
+func (c *Connector) HedgeStats() HedgeStats {
+	if c.hedger == nil {
+		return HedgeStats{}
+	}
+
+	c.hedger.mu.Lock()
+	defer c.hedger.mu.Unlock()
+
+	return c.hedger.stats
+}
+
+// accepts reports if req can be hedged. Only the reads are hedged, hedging
+// the other idempotent requests would double the write load of the upstream.
+func (h *hedger) accepts(req *http.Request) bool {
+	return h != nil && (req.Method == http.MethodGet || req.Method == http.MethodHead) && isReplayable(req)
+}
+
+// delay returns the hedging delay, false if it is not known yet.
+// It counts a new request.
+func (h *hedger) delay() (time.Duration, bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.stats.Requests++
+
+	if h.conf.Delay > 0 {
+		return h.conf.Delay, true
+	}
+	if len(h.latencies) < minHedgeSamples {
+		return 0, false
+	}
+
+	sorted := append([]time.Duration(nil), h.latencies...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	return sorted[int(math.Ceil(h.conf.Percentile*float64(len(sorted))))-1], true
+}
+
+// allow reports if a hedged attempt can be sent within the budget and counts it.
+func (h *hedger) allow() bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if float64(h.stats.Hedges+1) > h.conf.Budget*float64(h.stats.Requests) {
+		return false
+	}
+	h.stats.Hedges++
+
+	return true
+}
+
+// cancel uncounts a hedged attempt allowed but not sent.
+func (h *hedger) cancel() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.stats.Hedges--
+}
+
+// observe records the latency of a successful request and whether its
+// response came from the hedged attempt.
+func (h *hedger) observe(latency time.Duration, hedged bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if hedged {
+		h.stats.Wins++
+	}
+
+	if len(h.latencies) < hedgeWindow {
+		h.latencies = append(h.latencies, latency)
+		return
+	}
+	h.latencies[h.next] = latency
+	h.next = (h.next + 1) % hedgeWindow
+}
+
+// hedgeResult is the outcome of an attempt of a hedged request.
+type hedgeResult struct {
+	attempt  int
+	response *http.Response
+	err      error
+}
+
+// sendHedged sends a single attempt of req, and a hedged one if the first
+// attempt has not answered within the hedging delay. The first successful
+// response is returned and the other attempt is canceled. When every attempt
+// fails, the error of the last one is returned along its response, if any,
+// so the retry policy can still check its status code and Retry-After header.
+// A hedged attempt is only sent if the rate limiter has a token available
+// right away and the circuit breaker lets it through.
+func (c *Connector) sendHedged(req *http.Request, exceptedStatusCode StatusMatcher) (*http.Response, error) {
+	h := c.hedger
+	if !h.accepts(req) {
+		return c.sendBalanced(req, exceptedStatusCode)
+	}
+
+	delay, hedgeable := h.delay()
+	start := time.Now()
+	results := make(chan hedgeResult, 2)
+	var cancels []context.CancelFunc
+
+	// The first attempt goes through the rate limiter and the circuit breaker
+	// in sendWithRetry, the hedged one records its own result.
+	send := func(r *http.Request, hedged bool, generation uint64) {
+		ctx, cancel := context.WithCancel(r.Context())
+		attempt := len(cancels)
+		cancels = append(cancels, cancel)
+		go func() {
+			response, err := c.sendBalanced(r.WithContext(ctx), exceptedStatusCode)
+			if hedged {
+				c.breaker.record(generation, err)
+			}
+			results <- hedgeResult{attempt: attempt, response: response, err: err}
+		}()
+	}
+
+	send(req, false, 0)
+	inFlight := 1
+
+	var timer <-chan time.Time
+	if hedgeable {
+		t := time.NewTimer(delay)
+		defer t.Stop()
+		timer = t.C
+	}
+
+	var (
+		lastResponse *http.Response
+		lastErr      error
+	)
+	for inFlight > 0 {
+		select {
+		case <-timer:
+			timer = nil
+			r, err := rewindRequest(req)
+			if err != nil || !h.allow() {
+				continue
+			}
+			if !c.limiter.allow(r) {
+				h.cancel()
+				continue
+			}
+			generation, err := c.breaker.allow()
+			if err != nil {
+				c.limiter.release(r)
+				h.cancel()
+				continue
+			}
+			send(r, true, generation)
+			inFlight++
+
+		case result := <-results:
+			inFlight--
+			if result.err != nil {
+				cancels[result.attempt]()
+				lastResponse, lastErr = result.response, result.err
+				continue
+			}
+
+			h.observe(time.Since(start), result.attempt > 0)
+			for i, cancel := range cancels {
+				if i != result.attempt {
+					cancel()
+				}
+			}
+			go discardHedgeResults(results, inFlight)
+
+			result.response.Body = &cancelOnClose{ReadCloser: result.response.Body, cancel: cancels[result.attempt]}
+			return result.response, nil
+		}
+	}
+
+	return lastResponse, lastErr
+}
+
+// discardHedgeResults closes the responses of the n attempts still in flight.
+func discardHedgeResults(results <-chan hedgeResult, n int) {
+	for ; n > 0; n-- {
+		if result := <-results; result.err == nil {
+			result.response.Body.Close()
+		}
+	}
+}
+
+// cancelOnClose is a response body canceling the context of its request once closed.
+type cancelOnClose struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (b *cancelOnClose) Close() error {
+	err := b.ReadCloser.Close()
+	b.cancel()
+
+	return err
+}
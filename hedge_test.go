@@ -0,0 +1,214 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/Aloe-Corporation/client/test"
+)
+
+func TestConnector_Hedge(t *testing.T) {
+	tests := []struct {
+		name         string
+		conf         *HedgeConf
+		rateLimit    *RateLimitConf
+		method       string
+		header       http.Header
+		firstDelay   time.Duration
+		wantBody     string
+		wantMaxTime  time.Duration
+		wantUpstream int32
+		wantStats    HedgeStats
+	}{
+		{
+			name:         "Success case: hedged attempt wins",
+			conf:         &HedgeConf{Delay: 20 * time.Millisecond, Budget: 1},
+			method:       http.MethodGet,
+			firstDelay:   2 * time.Second,
+			wantBody:     "This is data 2",
+			wantMaxTime:  time.Second,
+			wantUpstream: 2,
+			wantStats:    HedgeStats{Requests: 1, Hedges: 1, Wins: 1},
+		},
+		{
+			name:         "Success case: first attempt answers in time",
+			conf:         &HedgeConf{Delay: time.Second, Budget: 1},
+			method:       http.MethodGet,
+			firstDelay:   10 * time.Millisecond,
+			wantBody:     "This is data 1",
+			wantMaxTime:  time.Second,
+			wantUpstream: 1,
+			wantStats:    HedgeStats{Requests: 1},
+		},
+		{
+			name:         "Success case: budget exhausted",
+			conf:         &HedgeConf{Delay: 20 * time.Millisecond},
+			method:       http.MethodGet,
+			firstDelay:   100 * time.Millisecond,
+			wantBody:     "This is data 1",
+			wantMaxTime:  time.Second,
+			wantUpstream: 1,
+			wantStats:    HedgeStats{Requests: 1},
+		},
+		{
+			name:         "Success case: connector rate limit token not available",
+			conf:         &HedgeConf{Delay: 20 * time.Millisecond, Budget: 1},
+			rateLimit:    &RateLimitConf{RequestsPerSecond: 0.001},
+			method:       http.MethodGet,
+			firstDelay:   100 * time.Millisecond,
+			wantBody:     "This is data 1",
+			wantMaxTime:  time.Second,
+			wantUpstream: 1,
+			wantStats:    HedgeStats{Requests: 1},
+		},
+		{
+			name:         "Success case: path rate limit token not available",
+			conf:         &HedgeConf{Delay: 20 * time.Millisecond, Budget: 1},
+			rateLimit:    &RateLimitConf{Paths: map[string]RateLimit{"/data": {RequestsPerSecond: 0.001}}},
+			method:       http.MethodGet,
+			firstDelay:   100 * time.Millisecond,
+			wantBody:     "This is data 1",
+			wantMaxTime:  time.Second,
+			wantUpstream: 1,
+			wantStats:    HedgeStats{Requests: 1},
+		},
+		{
+			name:         "Success case: non idempotent request",
+			conf:         &HedgeConf{Delay: 20 * time.Millisecond, Budget: 1},
+			method:       http.MethodPost,
+			firstDelay:   100 * time.Millisecond,
+			wantBody:     "This is data 1",
+			wantMaxTime:  time.Second,
+			wantUpstream: 1,
+		},
+		{
+			name:         "Success case: idempotent write request",
+			conf:         &HedgeConf{Delay: 20 * time.Millisecond, Budget: 1},
+			method:       http.MethodPut,
+			firstDelay:   100 * time.Millisecond,
+			wantBody:     "This is data 1",
+			wantMaxTime:  time.Second,
+			wantUpstream: 1,
+		},
+		{
+			name:         "Success case: POST request with idempotency key",
+			conf:         &HedgeConf{Delay: 20 * time.Millisecond, Budget: 1},
+			method:       http.MethodPost,
+			header:       http.Header{"Idempotency-Key": {"42"}},
+			firstDelay:   100 * time.Millisecond,
+			wantBody:     "This is data 1",
+			wantMaxTime:  time.Second,
+			wantUpstream: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, counter := test.SlowFirstEndpoint(tt.firstDelay)
+			defer server.Close()
+
+			c := factoryConnector(Conf{URL: server.URL, PingEndpoint: "/", Hedge: tt.conf, RateLimit: tt.rateLimit}, FactoryHTTPClient())
+
+			header := tt.header.Clone()
+			if header == nil {
+				header = http.Header{}
+			}
+
+			start := time.Now()
+			got, err := c.DoWithHeaderCtx(context.Background(), tt.method, "/data", &header, bytes.NewReader(nil), DefaultStatusRange)
+			if err != nil {
+				t.Fatalf("Connector.DoWithHeaderCtx() error = %v", err)
+			}
+			if elapsed := time.Since(start); elapsed > tt.wantMaxTime {
+				t.Errorf("Connector.DoWithHeaderCtx() took %v, want less than %v", elapsed, tt.wantMaxTime)
+			}
+			if string(got) != tt.wantBody {
+				t.Errorf("Connector.DoWithHeaderCtx() = %q, want %q", got, tt.wantBody)
+			}
+			if got := counter.Load(); got != tt.wantUpstream {
+				t.Errorf("upstream requests = %d, want %d", got, tt.wantUpstream)
+			}
+			if got := c.HedgeStats(); got != tt.wantStats {
+				t.Errorf("Connector.HedgeStats() = %+v, want %+v", got, tt.wantStats)
+			}
+		})
+	}
+}
+
+func TestConnector_Hedge_HalfOpenCircuit(t *testing.T) {
+	server, counter := test.SlowFirstEndpoint(100 * time.Millisecond)
+	defer server.Close()
+
+	c := factoryConnector(Conf{
+		URL:            server.URL,
+		PingEndpoint:   "/",
+		Hedge:          &HedgeConf{Delay: 20 * time.Millisecond, Budget: 1},
+		CircuitBreaker: &CircuitBreakerConf{FailureThreshold: 1, OpenTimeout: time.Minute, HalfOpenProbes: 1},
+	}, FactoryHTTPClient())
+
+	// Open the circuit, then let the open timeout elapse.
+	generation, _ := c.breaker.allow()
+	c.breaker.record(generation, &FailRequestError{Code: http.StatusInternalServerError})
+	c.breaker.now = func() time.Time { return time.Now().Add(time.Minute) }
+
+	// The first attempt takes the only probe slot, the hedged one is not sent.
+	got, err := c.SimpleGet("/data")
+	if err != nil {
+		t.Fatalf("Connector.SimpleGet() error = %v", err)
+	}
+	if string(got) != "This is data 1" {
+		t.Errorf("Connector.SimpleGet() = %q, want %q", got, "This is data 1")
+	}
+	if got := counter.Load(); got != 1 {
+		t.Errorf("upstream requests = %d, want 1", got)
+	}
+	if got := c.HedgeStats(); got != (HedgeStats{Requests: 1}) {
+		t.Errorf("Connector.HedgeStats() = %+v, want %+v", got, HedgeStats{Requests: 1})
+	}
+	if got := c.CircuitState(); got != CircuitClosed {
+		t.Errorf("Connector.CircuitState() = %v, want %v", got, CircuitClosed)
+	}
+}
+
+func TestConnector_Hedge_Retry(t *testing.T) {
+	server, counter := test.FlakyEndpoint(1, http.StatusServiceUnavailable, "0")
+	defer server.Close()
+
+	c := factoryConnector(Conf{
+		URL:          server.URL,
+		PingEndpoint: "/",
+		Hedge:        &HedgeConf{Delay: time.Second},
+		Retry:        &RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Millisecond},
+	}, FactoryHTTPClient())
+
+	got, err := c.SimpleGet("/")
+	if err != nil {
+		t.Fatalf("Connector.SimpleGet() error = %v", err)
+	}
+	if string(got) != "This is data" {
+		t.Errorf("Connector.SimpleGet() = %q, want %q", got, "This is data")
+	}
+	if got := counter.Load(); got != 2 {
+		t.Errorf("upstream requests = %d, want 2", got)
+	}
+}
+
+func TestHedger_delay(t *testing.T) {
+	h := newHedger(&HedgeConf{})
+
+	for i := 1; i < minHedgeSamples; i++ {
+		h.observe(time.Duration(i)*time.Millisecond, false)
+	}
+	if _, ok := h.delay(); ok {
+		t.Error("hedger.delay() should not be known before enough samples")
+	}
+
+	for i := minHedgeSamples; i <= 100; i++ {
+		h.observe(time.Duration(i)*time.Millisecond, false)
+	}
+	if got, ok := h.delay(); !ok || got != 95*time.Millisecond {
+		t.Errorf("hedger.delay() = %v, %v, want %v", got, ok, 95*time.Millisecond)
+	}
+}
@@ -0,0 +1,133 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+const (
+	// maxDecodeErrorBody is the maximum number of body bytes reported in a DecodeError.
+	maxDecodeErrorBody = 512
+	// mimeJSON is the media type of JSON request and response bodies.
+	mimeJSON = "application/json"
+)
+
+// DecodeError is returned by the JSON helpers when the response body can't be decoded.
+type DecodeError struct {
+	Method   string // HTTP method of the request
+	Endpoint string // Path of the request
+	Body     []byte // Response body, truncated to 512 bytes
+	Err      error  // Underlying decoding error
+}
+
+func (e *DecodeError) Error() string {
+	return fmt.Sprintf("can't decode JSON response of %s %s: %s, response body: %s",
+		e.Method, e.Endpoint, e.Err.Error(), e.Body)
+}
+
+// Unwrap returns the underlying decoding error.
+func (e *DecodeError) Unwrap() error {
+	return e.Err
+}
+
+// GetJSON sends a GET request to path and decodes the JSON response body in a T.
+// The StatusCodeRange used is DefaultStatusRange [200,400[.
+func GetJSON[T any](c *Connector, path string) (T, error) {
+	return GetJSONCtx[T](context.Background(), c, path)
+}
+
+// GetJSONCtx is the context aware version of GetJSON.
+func GetJSONCtx[T any](ctx context.Context, c *Connector, path string) (T, error) {
+	return doJSON[T](ctx, c, http.MethodGet, path, nil)
+}
+
+// PostJSON encodes body in JSON, sends it to path with a POST request and
+// decodes the JSON response body in a Resp.
+// The StatusCodeRange used is DefaultStatusRange [200,400[.
+func PostJSON[Req, Resp any](c *Connector, path string, body Req) (Resp, error) {
+	return PostJSONCtx[Req, Resp](context.Background(), c, path, body)
+}
+
+// PostJSONCtx is the context aware version of PostJSON.
+func PostJSONCtx[Req, Resp any](ctx context.Context, c *Connector, path string, body Req) (Resp, error) {
+	return doJSON[Resp](ctx, c, http.MethodPost, path, body)
+}
+
+// PutJSON encodes body in JSON, sends it to path with a PUT request and
+// decodes the JSON response body in a Resp.
+// The StatusCodeRange used is DefaultStatusRange [200,400[.
+func PutJSON[Req, Resp any](c *Connector, path string, body Req) (Resp, error) {
+	return PutJSONCtx[Req, Resp](context.Background(), c, path, body)
+}
+
+// PutJSONCtx is the context aware version of PutJSON.
+func PutJSONCtx[Req, Resp any](ctx context.Context, c *Connector, path string, body Req) (Resp, error) {
+	return doJSON[Resp](ctx, c, http.MethodPut, path, body)
+}
+
+// DeleteJSON sends a DELETE request to path and decodes the JSON response body in a T.
+// The StatusCodeRange used is DefaultStatusRange [200,400[.
+func DeleteJSON[T any](c *Connector, path string) (T, error) {
+	return DeleteJSONCtx[T](context.Background(), c, path)
+}
+
+// DeleteJSONCtx is the context aware version of DeleteJSON.
+func DeleteJSONCtx[T any](ctx context.Context, c *Connector, path string) (T, error) {
+	return doJSON[T](ctx, c, http.MethodDelete, path, nil)
+}
+
+// doJSON sends a request with body encoded in JSON if not nil, and decodes the
+// JSON response body in a T. An empty response body results in the zero value of T.
+func doJSON[T any](ctx context.Context, c *Connector, method, path string, body any) (T, error) {
+	var result T
+
+	header := http.Header{}
+	header.Set("Accept", mimeJSON)
+
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return result, fmt.Errorf("can't encode JSON request body of %s %s: %w", method, path, err)
+		}
+		reader = bytes.NewReader(data)
+		header.Set("Content-Type", mimeJSON)
+	}
+
+	data, err := c.DoWithHeaderCtx(ctx, method, path, &header, reader, DefaultStatusRange)
+	if err != nil {
+		return result, err
+	}
+
+	if len(bytes.TrimSpace(data)) == 0 {
+		return result, nil
+	}
+
+	if err := json.Unmarshal(data, &result); err != nil {
+		return result, &DecodeError{
+			Method:   method,
+			Endpoint: path,
+			Body:     truncate(data, maxDecodeErrorBody),
+			Err:      err,
+		}
+	}
+
+	return result, nil
+}
+
+// truncate returns the first n bytes of data followed by an ellipsis
+// if data is longer than n.
+func truncate(data []byte, n int) []byte {
+	if len(data) <= n {
+		return data
+	}
+
+	truncated := make([]byte, 0, n+3)
+	truncated = append(truncated, data[:n]...)
+
+	return append(truncated, "..."...)
+}
@@ -0,0 +1,154 @@
+package client
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/Aloe-Corporation/client/test"
+)
+
+type jsonItem struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+}
+
+func TestGetJSON(t *testing.T) {
+	tests := []struct {
+		name          string
+		path          string
+		want          jsonItem
+		wantErr       bool
+		wantDecodeErr bool
+	}{
+		{
+			name:    "Success case",
+			path:    "/json",
+			want:    jsonItem{ID: 1, Name: "test"},
+			wantErr: false,
+		},
+		{
+			name:    "Fail case: wrong path",
+			path:    "/wrong",
+			wantErr: true,
+		},
+		{
+			name:          "Fail case: invalid JSON",
+			path:          "/invalid",
+			wantErr:       true,
+			wantDecodeErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := test.JSONEndpoint()
+			defer server.Close()
+			c := &Connector{
+				Client: FactoryHTTPClient(),
+				URL:    server.URL,
+			}
+
+			got, err := GetJSON[jsonItem](c, tt.path)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("GetJSON() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+
+			var decodeErr *DecodeError
+			if errors.As(err, &decodeErr) != tt.wantDecodeErr {
+				t.Errorf("GetJSON() error = %v, wantDecodeErr %v", err, tt.wantDecodeErr)
+				return
+			}
+			if tt.wantDecodeErr && (decodeErr.Endpoint != tt.path || !strings.Contains(err.Error(), `{"id":`)) {
+				t.Errorf("GetJSON() error = %v, should contain the endpoint and the body", err)
+			}
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("GetJSON() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPostJSON(t *testing.T) {
+	server := test.JSONEndpoint()
+	defer server.Close()
+	c := &Connector{
+		Client: FactoryHTTPClient(),
+		URL:    server.URL,
+	}
+
+	want := jsonItem{ID: 2, Name: "posted"}
+	got, err := PostJSON[jsonItem, jsonItem](c, "/json", want)
+	if err != nil {
+		t.Fatalf("PostJSON() error = %v", err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("PostJSON() = %v, want %v", got, want)
+	}
+}
+
+func TestPutJSON(t *testing.T) {
+	server := test.JSONEndpoint()
+	defer server.Close()
+	c := &Connector{
+		Client: FactoryHTTPClient(),
+		URL:    server.URL,
+	}
+
+	want := map[string]string{"name": "put"}
+	got, err := PutJSON[map[string]string, map[string]string](c, "/json", want)
+	if err != nil {
+		t.Fatalf("PutJSON() error = %v", err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("PutJSON() = %v, want %v", got, want)
+	}
+}
+
+func TestDeleteJSON(t *testing.T) {
+	server := test.JSONEndpoint()
+	defer server.Close()
+	c := &Connector{
+		Client: FactoryHTTPClient(),
+		URL:    server.URL,
+	}
+
+	got, err := DeleteJSON[*jsonItem](c, "/json")
+	if err != nil {
+		t.Fatalf("DeleteJSON() error = %v", err)
+	}
+	if got != nil {
+		t.Errorf("DeleteJSON() = %v, want nil on empty response body", got)
+	}
+}
+
+func TestTruncate(t *testing.T) {
+	tests := []struct {
+		name string
+		data []byte
+		n    int
+		want []byte
+	}{
+		{
+			name: "Success case: short data",
+			data: []byte("short"),
+			n:    10,
+			want: []byte("short"),
+		},
+		{
+			name: "Success case: long data",
+			data: []byte("a long response body"),
+			n:    6,
+			want: []byte("a long..."),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := truncate(tt.data, tt.n); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("truncate() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
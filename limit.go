@@ -0,0 +1,79 @@
+package client
+
+import (
+	"context"
+	"io"
+	"net/http"
+)
+
+// maxResponseBytesKey is the context key of the per request response size limit.
+type maxResponseBytesKey struct{}
+
+// WithMaxResponseBytes returns a copy of ctx carrying a response body size limit.
+// It overrides Conf.MaxResponseBytes for the requests built with the returned context.
+func WithMaxResponseBytes(ctx context.Context, n int64) context.Context {
+	return context.WithValue(ctx, maxResponseBytesKey{}, n)
+}
+
+// maxResponseBytes returns the response body size limit of req, zero if unlimited.
+func (c *Connector) maxResponseBytes(req *http.Request) int64 {
+	if n, ok := req.Context().Value(maxResponseBytesKey{}).(int64); ok && n > 0 {
+		return n
+	}
+
+	return c.maxBodyBytes
+}
+
+// maxErrorBytes returns the maximum number of response body bytes kept in a FailRequestError.
+func (c *Connector) maxErrorBytes() int64 {
+	if c.maxErrorBodyBytes > 0 {
+		return c.maxErrorBodyBytes
+	}
+
+	return defaultMaxErrorBodyBytes
+}
+
+// limitBody bounds the body of response to the size limit of req.
+// It fails right away when the announced Content-Length exceeds the limit.
+func (c *Connector) limitBody(req *http.Request, response *http.Response) error {
+	limit := c.maxResponseBytes(req)
+	if limit <= 0 {
+		return nil
+	}
+
+	if response.ContentLength > limit {
+		response.Body.Close()
+		return &ResponseTooLargeError{Limit: limit, Read: 0}
+	}
+
+	response.Body = &limitedBody{ReadCloser: response.Body, limit: limit}
+
+	return nil
+}
+
+// limitedBody is a response body returning a *ResponseTooLargeError once more
+// than limit bytes are read.
+type limitedBody struct {
+	io.ReadCloser
+	limit int64 // Maximum number of bytes
+	read  int64 // Number of bytes read so far
+}
+
+func (b *limitedBody) Read(p []byte) (int, error) {
+	if b.read > b.limit {
+		return 0, &ResponseTooLargeError{Limit: b.limit, Read: b.read}
+	}
+
+	// Read one byte past the limit to detect bodies exceeding it.
+	if remaining := b.limit + 1 - b.read; int64(len(p)) > remaining {
+		p = p[:remaining]
+	}
+
+	n, err := b.ReadCloser.Read(p)
+	b.read += int64(n)
+	if b.read > b.limit {
+		return n - int(b.read-b.limit), &ResponseTooLargeError{Limit: b.limit, Read: b.read}
+	}
+
+	return n, err
+}
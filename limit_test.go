@@ -0,0 +1,126 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/Aloe-Corporation/client/test"
+)
+
+func TestConnector_DoWithStatusCheck_MaxResponseBytes(t *testing.T) {
+	type args struct {
+		size              int
+		status            int
+		maxBodyBytes      int64
+		maxErrorBodyBytes int64
+		requestLimit      int64
+	}
+	tests := []struct {
+		name          string
+		args          args
+		wantSize      int
+		wantTooLarge  bool
+		wantErrorBody int
+	}{
+		{
+			name: "Success case: body within limit",
+			args: args{
+				size:         100 << 10,
+				status:       http.StatusOK,
+				maxBodyBytes: 100 << 10,
+			},
+			wantSize: 100 << 10,
+		},
+		{
+			name: "Success case: per request limit overrides connector limit",
+			args: args{
+				size:         100 << 10,
+				status:       http.StatusOK,
+				maxBodyBytes: 1 << 10,
+				requestLimit: 200 << 10,
+			},
+			wantSize: 100 << 10,
+		},
+		{
+			name: "Fail case: streamed body exceeds limit",
+			args: args{
+				size:         100 << 10,
+				status:       http.StatusOK,
+				maxBodyBytes: 10 << 10,
+			},
+			wantTooLarge: true,
+		},
+		{
+			name: "Fail case: announced Content-Length exceeds limit",
+			args: args{
+				size:         1 << 10,
+				status:       http.StatusOK,
+				maxBodyBytes: 512,
+			},
+			wantTooLarge: true,
+		},
+		{
+			name: "Fail case: per request limit exceeded",
+			args: args{
+				size:         100 << 10,
+				status:       http.StatusOK,
+				requestLimit: 1 << 10,
+			},
+			wantTooLarge: true,
+		},
+		{
+			name: "Fail case: error body capped",
+			args: args{
+				size:              100 << 10,
+				status:            http.StatusInternalServerError,
+				maxBodyBytes:      1 << 20,
+				maxErrorBodyBytes: 256,
+			},
+			wantErrorBody: 256,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := test.LargeBodyEndpoint(tt.args.size, tt.args.status)
+			defer server.Close()
+			c := &Connector{
+				Client:            FactoryHTTPClient(),
+				URL:               server.URL,
+				maxBodyBytes:      tt.args.maxBodyBytes,
+				maxErrorBodyBytes: tt.args.maxErrorBodyBytes,
+			}
+
+			ctx := context.Background()
+			if tt.args.requestLimit > 0 {
+				ctx = WithMaxResponseBytes(ctx, tt.args.requestLimit)
+			}
+
+			got, err := c.SimpleGetCtx(ctx, "/")
+
+			var tooLarge *ResponseTooLargeError
+			if errors.As(err, &tooLarge) != tt.wantTooLarge || errors.Is(err, ErrResponseTooLarge) != tt.wantTooLarge {
+				t.Fatalf("Connector.SimpleGetCtx() error = %v, wantTooLarge %v", err, tt.wantTooLarge)
+			}
+			if tt.wantTooLarge {
+				if tooLarge.Limit <= 0 || tooLarge.Read > tooLarge.Limit+1 {
+					t.Errorf("Connector.SimpleGetCtx() error = %+v, should carry the limit and the bytes read", tooLarge)
+				}
+				return
+			}
+
+			var failErr *FailRequestError
+			if errors.As(err, &failErr) {
+				if len(failErr.ResponseBody) != tt.wantErrorBody {
+					t.Errorf("FailRequestError.ResponseBody has %d bytes, want %d", len(failErr.ResponseBody), tt.wantErrorBody)
+				}
+				return
+			}
+
+			if err != nil || len(got) != tt.wantSize {
+				t.Errorf("Connector.SimpleGetCtx() = %d bytes, error %v, want %d bytes", len(got), err, tt.wantSize)
+			}
+		})
+	}
+}
@@ -0,0 +1,268 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+const (
+	// defaultUnhealthyThreshold is the number of consecutive failed health checks
+	// marking the target API down when MonitorConf.UnhealthyThreshold is not set.
+	defaultUnhealthyThreshold = 1
+	// defaultHealthyThreshold is the number of consecutive successful health checks
+	// marking the target API up when MonitorConf.HealthyThreshold is not set.
+	defaultHealthyThreshold = 1
+)
+
+var (
+	// ErrUpstreamDown is returned without sending the request while the health
+	// monitor reports the target API down and MonitorConf.FailFast is set.
+	ErrUpstreamDown = errors.New("upstream is down")
+	// ErrMonitorRunning is returned by Connector.StartMonitor when the health
+	// monitor of the Connector is already running.
+	ErrMonitorRunning = errors.New("health monitor is already running")
+)
+
+// HealthStatus is the health of a target API as seen by the health monitor.
+type HealthStatus int
+
+const (
+	// HealthUnknown is the status before the first health check.
+	HealthUnknown HealthStatus = iota
+	// HealthUp is the status of a target API answering its health checks.
+	HealthUp
+	// HealthDown is the status of a target API failing its health checks.
+	HealthDown
+)
+
+// String returns the name of the status.
+func (s HealthStatus) String() string {
+	switch s {
+	case HealthUp:
+		return "up"
+	case HealthDown:
+		return "down"
+	default:
+		return "unknown"
+	}
+}
+
+// HealthState is a snapshot of the health of a target API.
+type HealthState struct {
+	Status              HealthStatus // Current status
+	LastSuccess         time.Time    // Time of the last successful health check
+	LastFailure         time.Time    // Time of the last failed health check
+	ConsecutiveFailures int          // Failed health checks since the last success
+	LastErr             error        // Error of the last failed health check
+}
+
+// MonitorConf configures the health monitor started by Connector.StartMonitor.
+// The target API is marked down after UnhealthyThreshold consecutive failed
+// health checks and up again after HealthyThreshold consecutive successful ones.
+type MonitorConf struct {
+	UnhealthyThreshold int           `yaml:"unhealthy_threshold"` // Consecutive failures marking the target API down, default 1
+	HealthyThreshold   int           `yaml:"healthy_threshold"`   // Consecutive successes marking the target API up, default 1
+	AttemptTimeout     time.Duration `yaml:"attempt_timeout"`     // Timeout of a health check, default the monitor interval
+	FailFast           bool          `yaml:"fail_fast"`           // Rejects requests with ErrUpstreamDown while the target API is down
+}
+
+// validate checks the consistency of the health monitor configuration.
+func (conf *MonitorConf) validate() []error {
+	if conf == nil {
+		return nil
+	}
+
+	var errs []error
+	errs = append(errs, validateNotNegative("unhealthy_threshold", conf.UnhealthyThreshold)...)
+	errs = append(errs, validateNotNegative("healthy_threshold", conf.HealthyThreshold)...)
+	errs = append(errs, validateNotNegative("attempt_timeout", conf.AttemptTimeout)...)
+
+	return errs
+}
+
+// healthMonitor holds the health of a target API. It is goroutine safe.
+// A nil *healthMonitor lets every request through.
+type healthMonitor struct {
+	conf MonitorConf
+
+	mu          sync.Mutex
+	running     bool
+	state       HealthState
+	successes   int // Consecutive successes while not up
+	subscribers map[int]func(from, to HealthState)
+	nextID      int
+}
+
+// newHealthMonitor returns a health monitor configured by conf.
+// The default configuration is used when conf is nil.
+func newHealthMonitor(conf *MonitorConf) *healthMonitor {
+	m := &healthMonitor{subscribers: make(map[int]func(from, to HealthState))}
+	if conf != nil {
+		m.conf = *conf
+	}
+	if m.conf.UnhealthyThreshold <= 0 {
+		m.conf.UnhealthyThreshold = defaultUnhealthyThreshold
+	}
+	if m.conf.HealthyThreshold <= 0 {
+		m.conf.HealthyThreshold = defaultHealthyThreshold
+	}
+
+	return m
+}
+
+// StartMonitor runs a health check of the target API every interval in a
+// background goroutine, until ctx is done. The current health is available
+// through Connector.HealthState and its changes are sent to the subscribers
+// registered with Connector.SubscribeHealth. It returns ErrMonitorRunning if
+// the monitor is already running.
+func (c *Connector) StartMonitor(ctx context.Context, interval time.Duration) error {
+	if c.monitor == nil {
+		return errors.New("can't start health monitor: the connector must be built by FactoryConnector")
+	}
+	if interval <= 0 {
+		return fmt.Errorf("can't start health monitor: invalid interval %v", interval)
+	}
+
+	if err := c.monitor.start(); err != nil {
+		return err
+	}
+
+	go c.runMonitor(ctx, interval)
+
+	return nil
+}
+
+// HealthState returns the current health of the target API.
+// Its status is HealthUnknown until the health monitor completes a health check.
+func (c *Connector) HealthState() HealthState {
+	if c.monitor == nil {
+		return HealthState{}
+	}
+
+	c.monitor.mu.Lock()
+	defer c.monitor.mu.Unlock()
+
+	return c.monitor.state
+}
+
+// SubscribeHealth registers fn to be called on every status change of the
+// target API. The callbacks are called sequentially from the monitor goroutine.
+// The returned function unregisters fn.
+func (c *Connector) SubscribeHealth(fn func(from, to HealthState)) (unsubscribe func()) {
+	if c.monitor == nil {
+		return func() {}
+	}
+
+	m := c.monitor
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	id := m.nextID
+	m.nextID++
+	m.subscribers[id] = fn
+
+	return func() {
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		delete(m.subscribers, id)
+	}
+}
+
+// runMonitor sends the health checks until ctx is done.
+func (c *Connector) runMonitor(ctx context.Context, interval time.Duration) {
+	defer c.monitor.stop()
+
+	timeout := c.monitor.conf.AttemptTimeout
+	if timeout <= 0 {
+		timeout = interval
+	}
+
+	for {
+		_, err := c.HealthCheck(ctx, HealthCheckOptions{MaxAttempts: 1, AttemptTimeout: timeout})
+		if ctx.Err() != nil {
+			return
+		}
+		c.monitor.record(err, time.Now())
+
+		if sleepCtx(ctx, interval) != nil {
+			return
+		}
+	}
+}
+
+// start marks the monitor as running.
+func (m *healthMonitor) start() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.running {
+		return ErrMonitorRunning
+	}
+	m.running = true
+
+	return nil
+}
+
+// stop marks the monitor as stopped.
+func (m *healthMonitor) stop() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.running = false
+}
+
+// record updates the health with the result of a health check.
+func (m *healthMonitor) record(err error, now time.Time) {
+	m.mu.Lock()
+	from := m.state
+
+	if err == nil {
+		m.state.LastSuccess = now
+		m.state.ConsecutiveFailures = 0
+		m.state.LastErr = nil
+		m.successes++
+		if m.state.Status != HealthUp && (m.state.Status == HealthUnknown || m.successes >= m.conf.HealthyThreshold) {
+			m.state.Status = HealthUp
+		}
+	} else {
+		m.state.LastFailure = now
+		m.state.ConsecutiveFailures++
+		m.state.LastErr = err
+		m.successes = 0
+		if m.state.Status != HealthDown && m.state.ConsecutiveFailures >= m.conf.UnhealthyThreshold {
+			m.state.Status = HealthDown
+		}
+	}
+
+	to := m.state
+	var subscribers []func(from, to HealthState)
+	if from.Status != to.Status {
+		for _, fn := range m.subscribers {
+			subscribers = append(subscribers, fn)
+		}
+	}
+	m.mu.Unlock()
+
+	for _, fn := range subscribers {
+		fn(from, to)
+	}
+}
+
+// allow returns ErrUpstreamDown if fail fast is enabled and the target API is down.
+func (m *healthMonitor) allow() error {
+	if m == nil || !m.conf.FailFast {
+		return nil
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.state.Status == HealthDown {
+		return ErrUpstreamDown
+	}
+
+	return nil
+}
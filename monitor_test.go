@@ -0,0 +1,123 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/Aloe-Corporation/client/test"
+)
+
+func TestConnector_StartMonitor(t *testing.T) {
+	server, _ := test.FlakyEndpoint(3, http.StatusServiceUnavailable, "")
+	defer server.Close()
+
+	c := factoryConnector(Conf{
+		URL:          server.URL,
+		PingEndpoint: "/",
+		Monitor:      &MonitorConf{UnhealthyThreshold: 2},
+	}, FactoryHTTPClient())
+
+	transitions := make(chan [2]HealthState, 10)
+	unsubscribe := c.SubscribeHealth(func(from, to HealthState) {
+		transitions <- [2]HealthState{from, to}
+	})
+	defer unsubscribe()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	if err := c.StartMonitor(ctx, 5*time.Millisecond); err != nil {
+		t.Fatalf("Connector.StartMonitor() error = %v", err)
+	}
+	if err := c.StartMonitor(ctx, 5*time.Millisecond); !errors.Is(err, ErrMonitorRunning) {
+		t.Errorf("Connector.StartMonitor() error = %v, want %v", err, ErrMonitorRunning)
+	}
+
+	want := []struct {
+		from, to HealthStatus
+		failures int
+	}{
+		{from: HealthUnknown, to: HealthDown, failures: 2},
+		{from: HealthDown, to: HealthUp, failures: 0},
+	}
+	for _, w := range want {
+		select {
+		case got := <-transitions:
+			if got[0].Status != w.from || got[1].Status != w.to {
+				t.Errorf("transition = %v -> %v, want %v -> %v", got[0].Status, got[1].Status, w.from, w.to)
+			}
+			if got[1].ConsecutiveFailures != w.failures {
+				t.Errorf("ConsecutiveFailures = %d, want %d", got[1].ConsecutiveFailures, w.failures)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("no transition to %v", w.to)
+		}
+	}
+
+	state := c.HealthState()
+	if state.Status != HealthUp || state.LastSuccess.IsZero() || state.LastFailure.IsZero() || state.LastErr != nil {
+		t.Errorf("Connector.HealthState() = %+v", state)
+	}
+}
+
+func TestConnector_MonitorFailFast(t *testing.T) {
+	tests := []struct {
+		name     string
+		failFast bool
+		status   HealthStatus
+		wantErr  error
+	}{
+		{
+			name:     "Success case: upstream up",
+			failFast: true,
+			status:   HealthUp,
+		},
+		{
+			name:   "Success case: fail fast disabled",
+			status: HealthDown,
+		},
+		{
+			name:     "Fail case: upstream down",
+			failFast: true,
+			status:   HealthDown,
+			wantErr:  ErrUpstreamDown,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := test.GetEndpoint()
+			defer server.Close()
+
+			c := factoryConnector(Conf{
+				URL:          server.URL,
+				PingEndpoint: "/",
+				Monitor:      &MonitorConf{FailFast: tt.failFast},
+			}, FactoryHTTPClient())
+
+			if tt.status == HealthDown {
+				c.monitor.record(errors.New("connection refused"), time.Now())
+			} else {
+				c.monitor.record(nil, time.Now())
+			}
+
+			_, err := c.SimpleGet("/get")
+			if !errors.Is(err, tt.wantErr) || (tt.wantErr == nil && err != nil) {
+				t.Errorf("Connector.SimpleGet() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestConnector_StartMonitor_NotBuilt(t *testing.T) {
+	c := &Connector{Client: FactoryHTTPClient()}
+
+	if err := c.StartMonitor(context.Background(), time.Second); err == nil {
+		t.Error("Connector.StartMonitor() should fail")
+	}
+	if state := c.HealthState(); state.Status != HealthUnknown {
+		t.Errorf("Connector.HealthState() = %v, want %v", state.Status, HealthUnknown)
+	}
+}
@@ -0,0 +1,243 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// AuthOAuth2 selects the OAuth2ClientCredentials authenticator in AuthConf.
+	AuthOAuth2 = "oauth2"
+
+	// OAuth2AuthStyleHeader sends the client credentials with the HTTP basic authentication scheme.
+	OAuth2AuthStyleHeader = "header"
+	// OAuth2AuthStyleBody sends the client credentials in the token request body.
+	OAuth2AuthStyleBody = "body"
+
+	// defaultExpiryDelta is how long before its expiry a token is refreshed when
+	// OAuth2ClientCredentials.ExpiryDelta is not set.
+	defaultExpiryDelta = 10 * time.Second
+	// defaultTokenFetchTimeout bounds a token request.
+	defaultTokenFetchTimeout = 30 * time.Second
+	// maxTokenResponseBytes is the maximum size of a token response body.
+	maxTokenResponseBytes = 1 << 20
+)
+
+// TokenInvalidator is implemented by the authenticators whose credentials can be
+// refreshed. When the target API answers a request with a 401 status code,
+// the Connector calls Invalidate with the rejected request, then authenticates
+// and sends the request once more.
+type TokenInvalidator interface {
+	Invalidate(req *http.Request)
+}
+
+// OAuth2ClientCredentials is an Authenticator implementing the OAuth2 client
+// credentials grant (RFC 6749 section 4.4). Tokens are cached until shortly
+// before their expiry, and a single token request is sent at a time whatever
+// the number of concurrent requests waiting for a token.
+// It must not be copied after first use.
+type OAuth2ClientCredentials struct {
+	TokenURL       string        // Token endpoint of the authorization server
+	ClientID       string        // Client identifier
+	ClientSecret   string        // Client secret
+	Scopes         []string      // Optional requested scopes
+	EndpointParams url.Values    // Optional additional parameters of the token request, such as audience
+	AuthStyle      string        // One of "header" or "body", default "header"
+	ExpiryDelta    time.Duration // How long before its expiry a token is refreshed, default 10s
+	Client         *http.Client  // Client used to request tokens, default http.DefaultClient
+
+	now func() time.Time
+
+	mu       sync.Mutex
+	token    string     // Cached access token
+	expiry   time.Time  // Expiry of the cached token, zero if it never expires
+	inflight *tokenCall // Token request in progress, nil if none
+}
+
+// tokenCall is a token request shared by the concurrent callers of
+// OAuth2ClientCredentials.Token.
+type tokenCall struct {
+	done   chan struct{}
+	token  string
+	expiry time.Time
+	err    error
+}
+
+// tokenResponse is the successful response of the token endpoint.
+type tokenResponse struct {
+	AccessToken string `json:"access_token"`
+	TokenType   string `json:"token_type"`
+	ExpiresIn   int64  `json:"expires_in"`
+}
+
+// Authenticate sets the Authorization header of req with a valid access token.
+func (a *OAuth2ClientCredentials) Authenticate(req *http.Request) error {
+	token, err := a.Token(req.Context())
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Authorization", "Bearer "+token)
+	return nil
+}
+
+// Invalidate drops the cached token if it is the one used by req, so the next
+// call to Authenticate fetches a new token.
+func (a *OAuth2ClientCredentials) Invalidate(req *http.Request) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.token != "" && req.Header.Get("Authorization") == "Bearer "+a.token {
+		a.token = ""
+		a.expiry = time.Time{}
+	}
+}
+
+// Token returns the cached access token, or fetches a new one if it is missing
+// or about to expire. Concurrent callers share the same token request. The
+// wait for the token request is interrupted when ctx is done.
+func (a *OAuth2ClientCredentials) Token(ctx context.Context) (string, error) {
+	a.mu.Lock()
+	if a.valid() {
+		token := a.token
+		a.mu.Unlock()
+		return token, nil
+	}
+
+	call := a.inflight
+	if call == nil {
+		call = &tokenCall{done: make(chan struct{})}
+		a.inflight = call
+		go a.fetch(context.WithoutCancel(ctx), call)
+	}
+	a.mu.Unlock()
+
+	select {
+	case <-call.done:
+		return call.token, call.err
+	case <-ctx.Done():
+		return "", fmt.Errorf("can't get OAuth2 token: %w", ctx.Err())
+	}
+}
+
+// String returns a description of the authenticator without the client secret.
+func (a *OAuth2ClientCredentials) String() string {
+	return "OAuth2ClientCredentials{TokenURL:" + a.TokenURL + " ClientID:" + a.ClientID + " ClientSecret:" + redacted + "}"
+}
+
+// valid reports if the cached token can be used. The caller must hold the lock.
+func (a *OAuth2ClientCredentials) valid() bool {
+	if a.token == "" {
+		return false
+	}
+	if a.expiry.IsZero() {
+		return true
+	}
+
+	delta := a.ExpiryDelta
+	if delta <= 0 {
+		delta = defaultExpiryDelta
+	}
+
+	return a.clock().Add(delta).Before(a.expiry)
+}
+
+// fetch requests a token, caches it and wakes the callers waiting for call.
+func (a *OAuth2ClientCredentials) fetch(ctx context.Context, call *tokenCall) {
+	ctx, cancel := context.WithTimeout(ctx, defaultTokenFetchTimeout)
+	defer cancel()
+
+	call.token, call.expiry, call.err = a.requestToken(ctx)
+
+	a.mu.Lock()
+	if call.err == nil {
+		a.token = call.token
+		a.expiry = call.expiry
+	}
+	a.inflight = nil
+	a.mu.Unlock()
+
+	close(call.done)
+}
+
+// requestToken sends a token request to the token endpoint.
+func (a *OAuth2ClientCredentials) requestToken(ctx context.Context) (string, time.Time, error) {
+	form := url.Values{}
+	for key, values := range a.EndpointParams {
+		form[key] = values
+	}
+	form.Set("grant_type", "client_credentials")
+	if len(a.Scopes) > 0 {
+		form.Set("scope", strings.Join(a.Scopes, " "))
+	}
+	if a.AuthStyle == OAuth2AuthStyleBody {
+		form.Set("client_id", a.ClientID)
+		form.Set("client_secret", a.ClientSecret)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, a.TokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("can't create OAuth2 token request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", mimeJSON)
+	if a.AuthStyle != OAuth2AuthStyleBody {
+		req.SetBasicAuth(url.QueryEscape(a.ClientID), url.QueryEscape(a.ClientSecret))
+	}
+
+	client := a.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	start := a.clock()
+	response, err := client.Do(req)
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("can't fetch OAuth2 token: %w", err)
+	}
+	defer response.Body.Close()
+
+	data, err := io.ReadAll(io.LimitReader(response.Body, maxTokenResponseBytes))
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("can't read OAuth2 token response: %w", err)
+	}
+
+	if response.StatusCode < http.StatusOK || response.StatusCode >= http.StatusMultipleChoices {
+		return "", time.Time{}, fmt.Errorf("can't fetch OAuth2 token: %w",
+			&FailRequestError{Code: response.StatusCode, ResponseBody: data})
+	}
+
+	var token tokenResponse
+	if err := json.Unmarshal(data, &token); err != nil {
+		return "", time.Time{}, fmt.Errorf("can't decode OAuth2 token response: %w", err)
+	}
+	if token.AccessToken == "" {
+		return "", time.Time{}, fmt.Errorf("can't fetch OAuth2 token: response has no access_token")
+	}
+	if token.TokenType != "" && !strings.EqualFold(token.TokenType, "bearer") {
+		return "", time.Time{}, fmt.Errorf("can't use OAuth2 token: unsupported token type %q", token.TokenType)
+	}
+
+	var expiry time.Time
+	if token.ExpiresIn > 0 {
+		expiry = start.Add(time.Duration(token.ExpiresIn) * time.Second)
+	}
+
+	return token.AccessToken, expiry, nil
+}
+
+// clock returns the current time.
+func (a *OAuth2ClientCredentials) clock() time.Time {
+	if a.now != nil {
+		return a.now()
+	}
+
+	return time.Now()
+}
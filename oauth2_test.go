@@ -0,0 +1,212 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Aloe-Corporation/client/test"
+)
+
+func TestOAuth2ClientCredentials_Token(t *testing.T) {
+	tests := []struct {
+		name       string
+		authStyle  string
+		secret     string
+		expiresIn  int
+		calls      int
+		wantToken  string
+		wantErr    bool
+		wantIssued int32
+	}{
+		{
+			name:       "Success case: token cached",
+			secret:     "client-secret",
+			expiresIn:  3600,
+			calls:      3,
+			wantToken:  "token-1",
+			wantIssued: 1,
+		},
+		{
+			name:       "Success case: credentials in body",
+			authStyle:  OAuth2AuthStyleBody,
+			secret:     "client-secret",
+			expiresIn:  3600,
+			calls:      1,
+			wantToken:  "token-1",
+			wantIssued: 1,
+		},
+		{
+			name:       "Success case: token refreshed before expiry",
+			secret:     "client-secret",
+			expiresIn:  5,
+			calls:      2,
+			wantToken:  "token-2",
+			wantIssued: 2,
+		},
+		{
+			name:       "Fail case: invalid client",
+			secret:     "wrong",
+			expiresIn:  3600,
+			calls:      1,
+			wantErr:    true,
+			wantIssued: 0,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, counter := test.OAuth2TokenEndpoint(tt.expiresIn, 0)
+			defer server.Close()
+
+			a := &OAuth2ClientCredentials{
+				TokenURL:     server.URL,
+				ClientID:     "client-id",
+				ClientSecret: tt.secret,
+				AuthStyle:    tt.authStyle,
+			}
+
+			var got string
+			var err error
+			for i := 0; i < tt.calls; i++ {
+				got, err = a.Token(context.Background())
+			}
+
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("OAuth2ClientCredentials.Token() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil && strings.Contains(err.Error(), tt.secret) {
+				t.Errorf("OAuth2ClientCredentials.Token() error = %v, should not contain the secret", err)
+			}
+			if got != tt.wantToken {
+				t.Errorf("OAuth2ClientCredentials.Token() = %v, want %v", got, tt.wantToken)
+			}
+			if issued := counter.Load(); issued != tt.wantIssued {
+				t.Errorf("token endpoint issued %d tokens, want %d", issued, tt.wantIssued)
+			}
+		})
+	}
+}
+
+func TestOAuth2ClientCredentials_Token_SingleFlight(t *testing.T) {
+	server, counter := test.OAuth2TokenEndpoint(3600, 50*time.Millisecond)
+	defer server.Close()
+
+	a := &OAuth2ClientCredentials{
+		TokenURL:     server.URL,
+		ClientID:     "client-id",
+		ClientSecret: "client-secret",
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if got, err := a.Token(context.Background()); err != nil || got != "token-1" {
+				t.Errorf("OAuth2ClientCredentials.Token() = %v, %v, want token-1", got, err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if issued := counter.Load(); issued != 1 {
+		t.Errorf("token endpoint issued %d tokens, want 1", issued)
+	}
+}
+
+func TestOAuth2ClientCredentials_Token_Canceled(t *testing.T) {
+	server, _ := test.OAuth2TokenEndpoint(3600, time.Second)
+	defer server.Close()
+
+	a := &OAuth2ClientCredentials{
+		TokenURL:     server.URL,
+		ClientID:     "client-id",
+		ClientSecret: "client-secret",
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	if _, err := a.Token(ctx); err == nil {
+		t.Errorf("OAuth2ClientCredentials.Token() should fail when the context is done")
+	}
+}
+
+func TestConnector_SimpleGet_OAuth2(t *testing.T) {
+	tests := []struct {
+		name       string
+		validToken string
+		wantErr    bool
+		wantIssued int32
+	}{
+		{
+			name:       "Success case",
+			validToken: "token-1",
+			wantErr:    false,
+			wantIssued: 1,
+		},
+		{
+			name:       "Success case: rejected token refreshed once",
+			validToken: "token-2",
+			wantErr:    false,
+			wantIssued: 2,
+		},
+		{
+			name:       "Fail case: refreshed token rejected",
+			validToken: "token-3",
+			wantErr:    true,
+			wantIssued: 2,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tokenServer, counter := test.OAuth2TokenEndpoint(3600, 0)
+			defer tokenServer.Close()
+			server := test.BearerEndpoint(tt.validToken)
+			defer server.Close()
+
+			c := &Connector{
+				Client: FactoryHTTPClient(),
+				URL:    server.URL,
+				Authenticator: newAuthenticator(&AuthConf{
+					Type:         AuthOAuth2,
+					TokenURL:     tokenServer.URL,
+					ClientID:     "client-id",
+					ClientSecret: "client-secret",
+				}, nil),
+			}
+
+			_, err := c.SimpleGet("/get")
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Connector.SimpleGet() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil && !strings.Contains(err.Error(), "401") {
+				t.Errorf("Connector.SimpleGet() error = %v, want a 401 FailRequestError", err)
+			}
+			if issued := counter.Load(); issued != tt.wantIssued {
+				t.Errorf("token endpoint issued %d tokens, want %d", issued, tt.wantIssued)
+			}
+		})
+	}
+}
+
+func TestOAuth2ClientCredentials_Invalidate(t *testing.T) {
+	a := &OAuth2ClientCredentials{token: "current"}
+
+	stale, _ := http.NewRequest(http.MethodGet, "https://myserver.com", nil)
+	stale.Header.Set("Authorization", "Bearer previous")
+	a.Invalidate(stale)
+	if a.token != "current" {
+		t.Errorf("OAuth2ClientCredentials.Invalidate() dropped a token not used by the request")
+	}
+
+	rejected, _ := http.NewRequest(http.MethodGet, "https://myserver.com", nil)
+	rejected.Header.Set("Authorization", "Bearer current")
+	a.Invalidate(rejected)
+	if a.token != "" {
+		t.Errorf("OAuth2ClientCredentials.Invalidate() should drop the token used by the request")
+	}
+}
@@ -0,0 +1,267 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// RateLimit defines a token bucket refilled with RequestsPerSecond tokens per second
+// and holding at most Burst tokens.
+type RateLimit struct {
+	RequestsPerSecond float64 `yaml:"requests_per_second"` // Sustained request rate, no limit when zero
+	Burst             int     `yaml:"burst"`               // Maximum number of requests sent at once, default 1
+}
+
+// RateLimitConf configures the client side rate limiting of a Connector.
+// Every request waits for a token of the Connector limit, then for a token of
+// the longest matching path limit. The keys of Paths are path prefixes relative
+// to the Connector base URL such as "/search", matching whole path segments:
+// "/search" limits "/search/users" but not "/searchable".
+type RateLimitConf struct {
+	RequestsPerSecond float64              `yaml:"requests_per_second"` // Sustained request rate, no limit when zero
+	Burst             int                  `yaml:"burst"`               // Maximum number of requests sent at once, default 1
+	Paths             map[string]RateLimit `yaml:"paths"`               // Optional limits of endpoints with their own quota
+}
+
+// validate checks the consistency of the rate limiting configuration.
+func (conf *RateLimitConf) validate() []error {
+	if conf == nil {
+		return nil
+	}
+
+	errs := RateLimit{RequestsPerSecond: conf.RequestsPerSecond, Burst: conf.Burst}.validate()
+	for prefix, limit := range conf.Paths {
+		if !strings.HasPrefix(prefix, "/") {
+			errs = append(errs, &ConfError{Field: "paths." + prefix, Reason: "must start with a slash"})
+		}
+		errs = append(errs, prefixConfErrors("paths."+prefix, limit.validate())...)
+	}
+
+	return errs
+}
+
+// validate checks the consistency of the rate limit.
+func (limit RateLimit) validate() []error {
+	var errs []error
+	errs = append(errs, validateNotNegative("requests_per_second", limit.RequestsPerSecond)...)
+	errs = append(errs, validateNotNegative("burst", limit.Burst)...)
+
+	return errs
+}
+
+// rateLimiter holds the token buckets of a Connector.
+// A nil *rateLimiter never waits.
+type rateLimiter struct {
+	basePath string       // Path of the Connector base URL, stripped before matching the paths
+	global   *tokenBucket // Connector limit, nil if unlimited
+	prefixes []string     // Path prefixes sorted from the longest to the shortest
+	paths    map[string]*tokenBucket
+}
+
+// newRateLimiter returns a rate limiter configured by conf, nil if conf is nil.
+func newRateLimiter(conf *RateLimitConf, baseURL string) *rateLimiter {
+	if conf == nil {
+		return nil
+	}
+
+	l := &rateLimiter{
+		global: newTokenBucket(RateLimit{RequestsPerSecond: conf.RequestsPerSecond, Burst: conf.Burst}),
+		paths:  make(map[string]*tokenBucket, len(conf.Paths)),
+	}
+	if u, err := url.Parse(baseURL); err == nil {
+		l.basePath = strings.TrimSuffix(u.Path, "/")
+	}
+
+	for prefix, limit := range conf.Paths {
+		if b := newTokenBucket(limit); b != nil {
+			l.paths[prefix] = b
+			l.prefixes = append(l.prefixes, prefix)
+		}
+	}
+	sort.Slice(l.prefixes, func(i, j int) bool {
+		return len(l.prefixes[i]) > len(l.prefixes[j])
+	})
+
+	return l
+}
+
+// wait blocks until req can be sent according to the limits, or until the
+// request context is done.
+func (l *rateLimiter) wait(req *http.Request) error {
+	if l == nil {
+		return nil
+	}
+
+	ctx := req.Context()
+	if err := l.global.wait(ctx); err != nil {
+		return fmt.Errorf("can't wait for rate limiter: %w", err)
+	}
+
+	if prefix, ok := l.match(req); ok {
+		if err := l.paths[prefix].wait(ctx); err != nil {
+			l.global.release()
+			return fmt.Errorf("can't wait for rate limiter of %s: %w", prefix, err)
+		}
+	}
+
+	return nil
+}
+
+// allow takes the tokens of req if they are available right away, and
+// reports if the request can be sent without waiting.
+func (l *rateLimiter) allow(req *http.Request) bool {
+	if l == nil {
+		return true
+	}
+
+	if !l.global.take() {
+		return false
+	}
+	if prefix, ok := l.match(req); ok && !l.paths[prefix].take() {
+		l.global.release()
+		return false
+	}
+
+	return true
+}
+
+// release gives back the tokens taken by rateLimiter.allow for req when the
+// request is not sent.
+func (l *rateLimiter) release(req *http.Request) {
+	if l == nil {
+		return
+	}
+
+	l.global.release()
+	if prefix, ok := l.match(req); ok {
+		l.paths[prefix].release()
+	}
+}
+
+// match returns the longest path prefix limiting req. A prefix matches whole
+// path segments only, so /search matches /search/users but not /searchable.
+func (l *rateLimiter) match(req *http.Request) (string, bool) {
+	path := strings.TrimPrefix(req.URL.Path, l.basePath)
+	for _, prefix := range l.prefixes {
+		if path == prefix || strings.HasPrefix(path, strings.TrimSuffix(prefix, "/")+"/") {
+			return prefix, true
+		}
+	}
+
+	return "", false
+}
+
+// tokenBucket is a goroutine safe token bucket.
+// A nil *tokenBucket never waits.
+type tokenBucket struct {
+	rate  float64 // Tokens added per second
+	burst float64 // Maximum number of tokens
+	now   func() time.Time
+
+	mu     sync.Mutex
+	tokens float64 // Available tokens, negative when reserved by waiting requests
+	last   time.Time
+}
+
+// newTokenBucket returns a full token bucket, nil if limit has no rate.
+func newTokenBucket(limit RateLimit) *tokenBucket {
+	if limit.RequestsPerSecond <= 0 {
+		return nil
+	}
+
+	burst := float64(limit.Burst)
+	if burst < 1 {
+		burst = 1
+	}
+
+	return &tokenBucket{
+		rate:   limit.RequestsPerSecond,
+		burst:  burst,
+		now:    time.Now,
+		tokens: burst,
+	}
+}
+
+// reserve takes a token and returns the time to wait before using it.
+// The token is not taken if the wait would exceed maxWait.
+func (b *tokenBucket) reserve(maxWait time.Duration) (time.Duration, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := b.now()
+	if !b.last.IsZero() {
+		b.tokens = math.Min(b.burst, b.tokens+now.Sub(b.last).Seconds()*b.rate)
+	}
+	b.last = now
+
+	var wait time.Duration
+	if b.tokens < 1 {
+		wait = time.Duration((1 - b.tokens) / b.rate * float64(time.Second))
+	}
+	if wait > maxWait {
+		return wait, false
+	}
+
+	b.tokens--
+	return wait, true
+}
+
+// take takes a token if one is available right away.
+func (b *tokenBucket) take() bool {
+	if b == nil {
+		return true
+	}
+
+	_, ok := b.reserve(0)
+	return ok
+}
+
+// release gives back a reserved token that will not be used.
+func (b *tokenBucket) release() {
+	if b == nil {
+		return
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.tokens = math.Min(b.burst, b.tokens+1)
+}
+
+// wait blocks until a token is available or ctx is done.
+func (b *tokenBucket) wait(ctx context.Context) error {
+	if b == nil {
+		return nil
+	}
+
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	maxWait := time.Duration(math.MaxInt64)
+	if deadline, ok := ctx.Deadline(); ok {
+		maxWait = time.Until(deadline)
+	}
+
+	wait, ok := b.reserve(maxWait)
+	if !ok {
+		return fmt.Errorf("would wait %s, longer than the context deadline: %w", wait, context.DeadlineExceeded)
+	}
+	if wait == 0 {
+		return nil
+	}
+
+	if err := sleepCtx(ctx, wait); err != nil {
+		b.release()
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,180 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/Aloe-Corporation/client/test"
+)
+
+func TestTokenBucket_reserve(t *testing.T) {
+	type step struct {
+		elapsed  time.Duration // Time elapsed since the beginning of the test
+		wantWait time.Duration
+	}
+	tests := []struct {
+		name  string
+		limit RateLimit
+		steps []step
+	}{
+		{
+			name:  "Success case: burst then wait",
+			limit: RateLimit{RequestsPerSecond: 10, Burst: 2},
+			steps: []step{
+				{wantWait: 0},
+				{wantWait: 0},
+				{wantWait: 100 * time.Millisecond},
+				{wantWait: 200 * time.Millisecond},
+			},
+		},
+		{
+			name:  "Success case: refill over time",
+			limit: RateLimit{RequestsPerSecond: 1, Burst: 1},
+			steps: []step{
+				{wantWait: 0},
+				{elapsed: 500 * time.Millisecond, wantWait: 500 * time.Millisecond},
+				{elapsed: 3 * time.Second, wantWait: 0},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			start := time.Now()
+			b := newTokenBucket(tt.limit)
+			for i, s := range tt.steps {
+				b.now = func() time.Time { return start.Add(s.elapsed) }
+				got, ok := b.reserve(time.Hour)
+				if !ok || got != s.wantWait {
+					t.Fatalf("step %d: tokenBucket.reserve() = %v, %v, want %v, true", i, got, ok, s.wantWait)
+				}
+			}
+		})
+	}
+}
+
+func TestRateLimiter_wait(t *testing.T) {
+	tests := []struct {
+		name    string
+		conf    *RateLimitConf
+		path    string
+		timeout time.Duration
+		wantErr bool
+	}{
+		{
+			name:    "Success case: no rate limit",
+			conf:    nil,
+			path:    "/get",
+			timeout: time.Second,
+			wantErr: false,
+		},
+		{
+			name:    "Success case: path limit not matching",
+			conf:    &RateLimitConf{Paths: map[string]RateLimit{"/search": {RequestsPerSecond: 0.001}}},
+			path:    "/api/get",
+			timeout: 10 * time.Millisecond,
+			wantErr: false,
+		},
+		{
+			name:    "Success case: path limit matching whole segments only",
+			conf:    &RateLimitConf{Paths: map[string]RateLimit{"/search": {RequestsPerSecond: 0.001}}},
+			path:    "/api/searchable",
+			timeout: 10 * time.Millisecond,
+			wantErr: false,
+		},
+		{
+			name:    "Fail case: connector limit exceeded before deadline",
+			conf:    &RateLimitConf{RequestsPerSecond: 0.001},
+			path:    "/api/get",
+			timeout: 10 * time.Millisecond,
+			wantErr: true,
+		},
+		{
+			name:    "Fail case: path limit exceeded before deadline",
+			conf:    &RateLimitConf{Paths: map[string]RateLimit{"/search": {RequestsPerSecond: 0.001}}},
+			path:    "/api/search/items",
+			timeout: 10 * time.Millisecond,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := newRateLimiter(tt.conf, "https://myserver.com/api")
+
+			ctx, cancel := context.WithTimeout(context.Background(), tt.timeout)
+			defer cancel()
+			req, err := http.NewRequestWithContext(ctx, http.MethodGet, "https://myserver.com"+tt.path, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			// The first request consumes the burst.
+			if err := l.wait(req); err != nil {
+				t.Fatalf("rateLimiter.wait() first request error = %v", err)
+			}
+
+			if err := l.wait(req); (err != nil) != tt.wantErr {
+				t.Errorf("rateLimiter.wait() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestRateLimiter_wait_ReleaseGlobal(t *testing.T) {
+	l := newRateLimiter(&RateLimitConf{
+		RequestsPerSecond: 0.001,
+		Burst:             2,
+		Paths:             map[string]RateLimit{"/search": {RequestsPerSecond: 0.001}},
+	}, "https://myserver.com")
+
+	newRequest := func(path string) *http.Request {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		t.Cleanup(cancel)
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, "https://myserver.com"+path, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return req
+	}
+
+	if err := l.wait(newRequest("/search")); err != nil {
+		t.Fatalf("rateLimiter.wait() first request error = %v", err)
+	}
+	if err := l.wait(newRequest("/search")); err == nil {
+		t.Fatal("rateLimiter.wait() error = nil, want the path limit exceeded")
+	}
+
+	// The connector token taken by the rejected request was given back.
+	if err := l.wait(newRequest("/get")); err != nil {
+		t.Errorf("rateLimiter.wait() error = %v, want the released connector token", err)
+	}
+}
+
+func TestConnector_SimpleGetCtx_RateLimit(t *testing.T) {
+	server := test.GetEndpoint()
+	defer server.Close()
+
+	c := &Connector{
+		Client:  FactoryHTTPClient(),
+		URL:     server.URL,
+		limiter: newRateLimiter(&RateLimitConf{RequestsPerSecond: 20, Burst: 1}, server.URL),
+	}
+
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		if _, err := c.SimpleGet("/get"); err != nil {
+			t.Fatalf("Connector.SimpleGet() error = %v", err)
+		}
+	}
+	if elapsed := time.Since(start); elapsed < 90*time.Millisecond {
+		t.Errorf("Connector.SimpleGet() sent 3 requests in %v, want at least 100ms", elapsed)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := c.SimpleGetCtx(ctx, "/get"); !errors.Is(err, context.Canceled) {
+		t.Errorf("Connector.SimpleGetCtx() error = %v, want %v", err, context.Canceled)
+	}
+}
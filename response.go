@@ -0,0 +1,92 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// Response is a response whose body was read, matched by the excepted status matcher.
+type Response struct {
+	StatusCode int           // Status code of the response, such as 201
+	Status     string        // Status line of the response, such as "201 Created"
+	Proto      string        // Protocol of the response, such as "HTTP/1.1"
+	Header     http.Header   // Headers of the response
+	Body       []byte        // Body of the response, bounded by the response size limit
+	URL        *url.URL      // URL of the final request, after the redirects, without the credentials of the authenticator
+	Duration   time.Duration // Elapsed time from sending the request to reading the body, retries included
+}
+
+// DoGet sends a GET request to path and returns the Response.
+// The status matcher used is DefaultStatusRange [200,400[.
+func (c *Connector) DoGet(ctx context.Context, path string) (*Response, error) {
+	return c.DoRequest(ctx, http.MethodGet, path, nil, nil, DefaultStatusRange)
+}
+
+// DoPost sends a POST request with body to path and returns the Response.
+// The status matcher used is DefaultStatusRange [200,400[.
+func (c *Connector) DoPost(ctx context.Context, path string, body io.Reader) (*Response, error) {
+	return c.DoRequest(ctx, http.MethodPost, path, nil, body, DefaultStatusRange)
+}
+
+// DoPut sends a PUT request with body to path and returns the Response.
+// The status matcher used is DefaultStatusRange [200,400[.
+func (c *Connector) DoPut(ctx context.Context, path string, body io.Reader) (*Response, error) {
+	return c.DoRequest(ctx, http.MethodPut, path, nil, body, DefaultStatusRange)
+}
+
+// DoDelete sends a DELETE request with body to path and returns the Response.
+// The status matcher used is DefaultStatusRange [200,400[.
+func (c *Connector) DoDelete(ctx context.Context, path string, body io.Reader) (*Response, error) {
+	return c.DoRequest(ctx, http.MethodDelete, path, nil, body, DefaultStatusRange)
+}
+
+// DoRequest is the Response returning version of Connector.DoWithHeaderCtx.
+func (c *Connector) DoRequest(ctx context.Context, method, path string, header *http.Header, body io.Reader, exceptedStatusCode StatusMatcher) (*Response, error) {
+	req, err := c.newRequest(ctx, method, path, body)
+	if err != nil {
+		return nil, err
+	}
+
+	if header != nil {
+		req.Header = *header
+	}
+
+	return c.DoResponse(req, exceptedStatusCode)
+}
+
+// DoResponse is the Response returning version of Connector.DoWithStatusCheck.
+func (c *Connector) DoResponse(req *http.Request, exceptedStatusCode StatusMatcher) (*Response, error) {
+	start := time.Now()
+
+	response, err := c.send(req, exceptedStatusCode)
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+
+	data, err := io.ReadAll(response.Body)
+	if err != nil {
+		return nil, fmt.Errorf("can't read response body : %w", err)
+	}
+
+	// The request sent was decorated by the authenticator, its credentials
+	// must not be exposed to the caller.
+	finalURL := req.URL
+	if response.Request != nil {
+		finalURL = c.stripAuthQuery(response.Request.URL)
+	}
+
+	return &Response{
+		StatusCode: response.StatusCode,
+		Status:     response.Status,
+		Proto:      response.Proto,
+		Header:     response.Header,
+		Body:       data,
+		URL:        finalURL,
+		Duration:   time.Since(start),
+	}, nil
+}
@@ -0,0 +1,118 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Aloe-Corporation/client/test"
+)
+
+func TestConnector_DoResponse(t *testing.T) {
+	tests := []struct {
+		name     string
+		server   func() *httptest.Server
+		do       func(c *Connector) (*Response, error)
+		wantPath string
+		wantErr  bool
+	}{
+		{
+			name:   "Success case: DoGet with redirects",
+			server: func() *httptest.Server { return test.RedirectEndpoint("") },
+			do: func(c *Connector) (*Response, error) {
+				return c.DoGet(context.Background(), "/redirect/2")
+			},
+			wantPath: "/get",
+		},
+		{
+			name:   "Success case: DoPost",
+			server: test.PostEndpoint,
+			do: func(c *Connector) (*Response, error) {
+				return c.DoPost(context.Background(), "/post", bytes.NewBufferString("data"))
+			},
+			wantPath: "/post",
+		},
+		{
+			name:   "Success case: DoPut",
+			server: test.PutEndpoint,
+			do: func(c *Connector) (*Response, error) {
+				return c.DoPut(context.Background(), "/put", bytes.NewBufferString("data"))
+			},
+			wantPath: "/put",
+		},
+		{
+			name:   "Success case: DoDelete",
+			server: test.DeleteEndpoint,
+			do: func(c *Connector) (*Response, error) {
+				return c.DoDelete(context.Background(), "/delete", nil)
+			},
+			wantPath: "/delete",
+		},
+		{
+			name:   "Success case: DoRequest with header",
+			server: test.GetEndpointWithHeader,
+			do: func(c *Connector) (*Response, error) {
+				return c.DoRequest(context.Background(), http.MethodGet, "/get", &http.Header{"Test-Header": {"value"}}, nil, Status2xx)
+			},
+			wantPath: "/get",
+		},
+		{
+			name:   "Fail case: status code not matched",
+			server: test.GetEndpoint,
+			do: func(c *Connector) (*Response, error) {
+				return c.DoGet(context.Background(), "/unknown")
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := tt.server()
+			defer server.Close()
+
+			c := factoryConnector(Conf{URL: server.URL, PingEndpoint: "/"}, FactoryHTTPClient())
+
+			got, err := tt.do(c)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Connector.DoResponse() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+
+			if got.StatusCode != http.StatusOK || got.Status != "200 OK" || got.Proto != "HTTP/1.1" {
+				t.Errorf("Connector.DoResponse() status = %d %q %q, want 200 \"200 OK\" \"HTTP/1.1\"", got.StatusCode, got.Status, got.Proto)
+			}
+			if string(got.Body) != "This is data" {
+				t.Errorf("Connector.DoResponse() body = %q, want %q", got.Body, "This is data")
+			}
+			if got.Header.Get("Content-Type") == "" {
+				t.Error("Connector.DoResponse() header Content-Type is missing")
+			}
+			if got.URL == nil || got.URL.Path != tt.wantPath {
+				t.Errorf("Connector.DoResponse() URL = %v, want path %q", got.URL, tt.wantPath)
+			}
+			if got.Duration <= 0 {
+				t.Errorf("Connector.DoResponse() duration = %v, want > 0", got.Duration)
+			}
+		})
+	}
+}
+
+func TestConnector_DoResponse_StripsCredentials(t *testing.T) {
+	server := test.GetEndpoint()
+	defer server.Close()
+
+	c := factoryConnector(Conf{URL: server.URL, PingEndpoint: "/"}, FactoryHTTPClient())
+	c.Authenticator = &APIKeyAuth{Name: "api_key", Value: "my-api-key", In: APIKeyInQuery}
+
+	got, err := c.DoGet(context.Background(), "/get?page=2")
+	if err != nil {
+		t.Fatalf("Connector.DoGet() error = %v", err)
+	}
+	if got.URL.RawQuery != "page=2" {
+		t.Errorf("Connector.DoGet() URL = %v, want the query page=2", got.URL)
+	}
+}
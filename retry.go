@@ -0,0 +1,227 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	// defaultInitialBackoff is the wait before the first retry when RetryPolicy.InitialBackoff is not set.
+	defaultInitialBackoff = 100 * time.Millisecond
+	// defaultMaxBackoff is the upper bound of the wait between two attempts when RetryPolicy.MaxBackoff is not set.
+	defaultMaxBackoff = 10 * time.Second
+	// defaultBackoffMultiplier is the backoff growth factor when RetryPolicy.Multiplier is not set.
+	defaultBackoffMultiplier = 2
+)
+
+var (
+	// DefaultRetryableStatusCodes are the status codes retried when RetryPolicy.RetryableStatusCodes is empty.
+	DefaultRetryableStatusCodes = []int{
+		http.StatusTooManyRequests,
+		http.StatusInternalServerError,
+		http.StatusBadGateway,
+		http.StatusServiceUnavailable,
+		http.StatusGatewayTimeout,
+	}
+)
+
+// RetryPolicy defines how a Connector retries a failed request.
+// A nil policy or a MaxAttempts lower than 2 disables retries.
+// Only idempotent requests (GET, HEAD, OPTIONS, TRACE, PUT, DELETE or requests
+// carrying an Idempotency-Key header) are retried unless RetryNonIdempotent is set.
+// A request with a body is retried only if its body can be replayed
+// through http.Request.GetBody.
+type RetryPolicy struct {
+	MaxAttempts          int              `yaml:"max_attempts"`           // Maximum number of attempts, first one included
+	InitialBackoff       time.Duration    `yaml:"initial_backoff"`        // Wait before the first retry, default 100ms
+	MaxBackoff           time.Duration    `yaml:"max_backoff"`            // Upper bound of the wait between two attempts, no retry on a longer Retry-After, default 10s
+	Multiplier           float64          `yaml:"multiplier"`             // Backoff growth factor between two attempts, default 2
+	Jitter               float64          `yaml:"jitter"`                 // Randomization factor in [0,1] applied to each backoff
+	RetryableStatusCodes []int            `yaml:"retryable_status_codes"` // Status codes to retry, default DefaultRetryableStatusCodes
+	RetryNonIdempotent   bool             `yaml:"retry_non_idempotent"`   // Allows retries of non idempotent methods such as POST
+	RetryableError       func(error) bool `yaml:"-"`                      // Classifies transport errors, default IsRetryableError
+}
+
+// validate checks the consistency of the retry policy.
+func (p *RetryPolicy) validate() []error {
+	if p == nil {
+		return nil
+	}
+
+	var errs []error
+	errs = append(errs, validateNotNegative("max_attempts", p.MaxAttempts)...)
+	errs = append(errs, validateNotNegative("initial_backoff", p.InitialBackoff)...)
+	errs = append(errs, validateNotNegative("max_backoff", p.MaxBackoff)...)
+	errs = append(errs, validateNotNegative("multiplier", p.Multiplier)...)
+
+	if p.Jitter < 0 || p.Jitter > 1 {
+		errs = append(errs, &ConfError{Field: "jitter", Reason: fmt.Sprintf("must be within [0,1], got %v", p.Jitter)})
+	}
+
+	for _, code := range p.RetryableStatusCodes {
+		if code < 100 || code > 599 {
+			errs = append(errs, &ConfError{Field: "retryable_status_codes", Reason: fmt.Sprintf("invalid status code %d", code)})
+		}
+	}
+
+	return errs
+}
+
+// maxAttempts returns the number of attempts allowed for req.
+func (p *RetryPolicy) maxAttempts(req *http.Request) int {
+	if p == nil || p.MaxAttempts < 2 {
+		return 1
+	}
+
+	if !p.RetryNonIdempotent && !isIdempotent(req) {
+		return 1
+	}
+
+	if !isReplayable(req) {
+		return 1
+	}
+
+	return p.MaxAttempts
+}
+
+// shouldRetry reports if the result of an attempt can be retried.
+// The response is only set when the status code was not the expected one.
+func (p *RetryPolicy) shouldRetry(response *http.Response, err error) bool {
+	if response != nil {
+		codes := p.RetryableStatusCodes
+		if len(codes) == 0 {
+			codes = DefaultRetryableStatusCodes
+		}
+		return isRetryableStatus(response.StatusCode, codes)
+	}
+
+	if p.RetryableError != nil {
+		return p.RetryableError(err)
+	}
+
+	return IsRetryableError(err)
+}
+
+// backoff returns the wait before the attempt following the given one.
+// The Retry-After header of 429 and 503 responses takes precedence over the
+// computed backoff, which is bounded by MaxBackoff. It returns false when the
+// Retry-After delay exceeds MaxBackoff: the server would reject an earlier
+// retry, so the request is not retried.
+func (p *RetryPolicy) backoff(attempt int, response *http.Response) (time.Duration, bool) {
+	maxBackoff := p.MaxBackoff
+	if maxBackoff <= 0 {
+		maxBackoff = defaultMaxBackoff
+	}
+
+	if response != nil &&
+		(response.StatusCode == http.StatusTooManyRequests || response.StatusCode == http.StatusServiceUnavailable) {
+		if d, ok := parseRetryAfter(response.Header.Get("Retry-After"), time.Now()); ok {
+			return d, d <= maxBackoff
+		}
+	}
+
+	initial := p.InitialBackoff
+	if initial <= 0 {
+		initial = defaultInitialBackoff
+	}
+	multiplier := p.Multiplier
+	if multiplier < 1 {
+		multiplier = defaultBackoffMultiplier
+	}
+
+	d := float64(initial) * math.Pow(multiplier, float64(attempt-1))
+	if p.Jitter > 0 {
+		d *= 1 + p.Jitter*(2*rand.Float64()-1) // #nosec G404 -- jitter does not need a secure source
+	}
+
+	return time.Duration(math.Min(d, float64(maxBackoff))), true
+}
+
+// IsRetryableError reports if a transport error returned by the native client
+// is worth a retry: timeouts, refused or reset connections and connections
+// closed before the response was received.
+// Context cancellation and deadlines are never retryable.
+func IsRetryableError(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+
+	return isTimeout(err) || isConnectionRefused(err) || isConnectionReset(err)
+}
+
+// isIdempotent reports if req can be sent several times without side effects.
+func isIdempotent(req *http.Request) bool {
+	switch req.Method {
+	case "", http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace, http.MethodPut, http.MethodDelete:
+		return true
+	}
+
+	return req.Header.Get("Idempotency-Key") != "" || req.Header.Get("X-Idempotency-Key") != ""
+}
+
+// parseRetryAfter parses a Retry-After header value given either
+// in seconds or as an HTTP date.
+func parseRetryAfter(value string, now time.Time) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			return 0, false
+		}
+		return time.Duration(seconds) * time.Second, true
+	}
+
+	date, err := http.ParseTime(value)
+	if err != nil {
+		return 0, false
+	}
+
+	if d := date.Sub(now); d > 0 {
+		return d, true
+	}
+
+	return 0, true
+}
+
+// isReplayable reports if req can be sent again, its body if any being
+// replayed through http.Request.GetBody.
+func isReplayable(req *http.Request) bool {
+	return req.Body == nil || req.Body == http.NoBody || req.GetBody != nil
+}
+
+// rewindRequest returns a copy of req with a fresh body for a new attempt.
+func rewindRequest(req *http.Request) (*http.Request, error) {
+	r := req.Clone(req.Context())
+	if req.GetBody == nil {
+		return r, nil
+	}
+
+	body, err := req.GetBody()
+	if err != nil {
+		return nil, err
+	}
+	r.Body = body
+
+	return r, nil
+}
+
+// sleepCtx waits for d or until ctx is done.
+func sleepCtx(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
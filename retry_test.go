@@ -0,0 +1,366 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/Aloe-Corporation/client/test"
+)
+
+func TestConnector_DoWithStatusCheck_Retry(t *testing.T) {
+	type args struct {
+		method     string
+		body       io.Reader
+		failures   int
+		status     int
+		retryAfter string
+		policy     *RetryPolicy
+	}
+	tests := []struct {
+		name         string
+		args         args
+		want         []byte
+		wantErr      bool
+		wantRequests int32
+	}{
+		{
+			name: "Success case: retried until success",
+			args: args{
+				method:   http.MethodGet,
+				failures: 2,
+				status:   http.StatusServiceUnavailable,
+				policy:   &RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Millisecond},
+			},
+			want:         []byte("This is data"),
+			wantErr:      false,
+			wantRequests: 3,
+		},
+		{
+			name: "Success case: body replayed on retry",
+			args: args{
+				method:   http.MethodPut,
+				body:     bytes.NewReader([]byte(" and body")),
+				failures: 1,
+				status:   http.StatusBadGateway,
+				policy:   &RetryPolicy{MaxAttempts: 2, InitialBackoff: time.Millisecond},
+			},
+			want:         []byte("This is data and body"),
+			wantErr:      false,
+			wantRequests: 2,
+		},
+		{
+			name: "Success case: Retry-After honoured",
+			args: args{
+				method:     http.MethodGet,
+				failures:   1,
+				status:     http.StatusTooManyRequests,
+				retryAfter: "0",
+				policy:     &RetryPolicy{MaxAttempts: 2, InitialBackoff: time.Hour},
+			},
+			want:         []byte("This is data"),
+			wantErr:      false,
+			wantRequests: 2,
+		},
+		{
+			name: "Success case: non idempotent method retried on opt in",
+			args: args{
+				method:   http.MethodPost,
+				body:     bytes.NewReader([]byte("")),
+				failures: 1,
+				status:   http.StatusServiceUnavailable,
+				policy:   &RetryPolicy{MaxAttempts: 2, InitialBackoff: time.Millisecond, RetryNonIdempotent: true},
+			},
+			want:         []byte("This is data"),
+			wantErr:      false,
+			wantRequests: 2,
+		},
+		{
+			name: "Fail case: attempts exhausted",
+			args: args{
+				method:   http.MethodGet,
+				failures: 5,
+				status:   http.StatusServiceUnavailable,
+				policy:   &RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Millisecond},
+			},
+			want:         nil,
+			wantErr:      true,
+			wantRequests: 3,
+		},
+		{
+			name: "Fail case: status code not retryable",
+			args: args{
+				method:   http.MethodGet,
+				failures: 1,
+				status:   http.StatusNotFound,
+				policy:   &RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Millisecond},
+			},
+			want:         nil,
+			wantErr:      true,
+			wantRequests: 1,
+		},
+		{
+			name: "Fail case: non idempotent method not retried",
+			args: args{
+				method:   http.MethodPost,
+				failures: 1,
+				status:   http.StatusServiceUnavailable,
+				policy:   &RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Millisecond},
+			},
+			want:         nil,
+			wantErr:      true,
+			wantRequests: 1,
+		},
+		{
+			name: "Fail case: Retry-After beyond max backoff",
+			args: args{
+				method:     http.MethodGet,
+				failures:   1,
+				status:     http.StatusServiceUnavailable,
+				retryAfter: "60",
+				policy:     &RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Millisecond, MaxBackoff: time.Second},
+			},
+			want:         nil,
+			wantErr:      true,
+			wantRequests: 1,
+		},
+		{
+			name: "Fail case: no retry policy",
+			args: args{
+				method:   http.MethodGet,
+				failures: 1,
+				status:   http.StatusServiceUnavailable,
+				policy:   nil,
+			},
+			want:         nil,
+			wantErr:      true,
+			wantRequests: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, counter := test.FlakyEndpoint(tt.args.failures, tt.args.status, tt.args.retryAfter)
+			defer server.Close()
+			c := &Connector{
+				Client: FactoryHTTPClient(),
+				URL:    server.URL,
+				retry:  tt.args.policy,
+			}
+
+			got, err := c.DoWithHeader(tt.args.method, "/", nil, tt.args.body, DefaultStatusRange)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Connector.DoWithHeader() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Connector.DoWithHeader() = %s, want %s", got, tt.want)
+			}
+			if got := counter.Load(); got != tt.wantRequests {
+				t.Errorf("Connector.DoWithHeader() sent %d requests, want %d", got, tt.wantRequests)
+			}
+		})
+	}
+}
+
+func TestRetryPolicy_backoff(t *testing.T) {
+	type args struct {
+		attempt  int
+		response *http.Response
+	}
+	tests := []struct {
+		name    string
+		policy  RetryPolicy
+		args    args
+		want    time.Duration
+		wantErr bool
+	}{
+		{
+			name:   "Success case: default values",
+			policy: RetryPolicy{},
+			args:   args{attempt: 1},
+			want:   defaultInitialBackoff,
+		},
+		{
+			name:   "Success case: exponential growth",
+			policy: RetryPolicy{InitialBackoff: time.Second, Multiplier: 3},
+			args:   args{attempt: 3},
+			want:   9 * time.Second,
+		},
+		{
+			name:   "Success case: capped by max backoff",
+			policy: RetryPolicy{InitialBackoff: time.Second, MaxBackoff: 5 * time.Second},
+			args:   args{attempt: 10},
+			want:   5 * time.Second,
+		},
+		{
+			name:   "Success case: Retry-After on 503",
+			policy: RetryPolicy{InitialBackoff: time.Second},
+			args: args{
+				attempt: 1,
+				response: &http.Response{
+					StatusCode: http.StatusServiceUnavailable,
+					Header:     http.Header{"Retry-After": []string{"7"}},
+				},
+			},
+			want: 7 * time.Second,
+		},
+		{
+			name:   "Success case: Retry-After equal to max backoff",
+			policy: RetryPolicy{InitialBackoff: time.Second, MaxBackoff: 30 * time.Second},
+			args: args{
+				attempt: 1,
+				response: &http.Response{
+					StatusCode: http.StatusTooManyRequests,
+					Header:     http.Header{"Retry-After": []string{"30"}},
+				},
+			},
+			want: 30 * time.Second,
+		},
+		{
+			name:   "Fail case: Retry-After beyond max backoff",
+			policy: RetryPolicy{InitialBackoff: time.Second, MaxBackoff: 30 * time.Second},
+			args: args{
+				attempt: 1,
+				response: &http.Response{
+					StatusCode: http.StatusTooManyRequests,
+					Header:     http.Header{"Retry-After": []string{"86400"}},
+				},
+			},
+			want:    24 * time.Hour,
+			wantErr: true,
+		},
+		{
+			name:   "Fail case: Retry-After beyond default max backoff",
+			policy: RetryPolicy{},
+			args: args{
+				attempt: 1,
+				response: &http.Response{
+					StatusCode: http.StatusServiceUnavailable,
+					Header:     http.Header{"Retry-After": []string{"60"}},
+				},
+			},
+			want:    time.Minute,
+			wantErr: true,
+		},
+		{
+			name:   "Success case: Retry-After ignored on 500",
+			policy: RetryPolicy{InitialBackoff: time.Second},
+			args: args{
+				attempt: 1,
+				response: &http.Response{
+					StatusCode: http.StatusInternalServerError,
+					Header:     http.Header{"Retry-After": []string{"7"}},
+				},
+			},
+			want: time.Second,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := tt.policy.backoff(tt.args.attempt, tt.args.response)
+			if got != tt.want || ok == tt.wantErr {
+				t.Errorf("RetryPolicy.backoff() = %v, %v, want %v, %v", got, ok, tt.want, !tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestRetryPolicy_backoff_Jitter(t *testing.T) {
+	p := RetryPolicy{InitialBackoff: time.Second, Jitter: 0.5}
+	for i := 0; i < 100; i++ {
+		got, _ := p.backoff(1, nil)
+		if got < 500*time.Millisecond || got > 1500*time.Millisecond {
+			t.Fatalf("RetryPolicy.backoff() = %v, want within [500ms, 1.5s]", got)
+		}
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2023, time.November, 1, 10, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name   string
+		value  string
+		want   time.Duration
+		wantOk bool
+	}{
+		{
+			name:   "Success case: seconds",
+			value:  "120",
+			want:   2 * time.Minute,
+			wantOk: true,
+		},
+		{
+			name:   "Success case: HTTP date",
+			value:  now.Add(30 * time.Second).Format(http.TimeFormat),
+			want:   30 * time.Second,
+			wantOk: true,
+		},
+		{
+			name:   "Success case: HTTP date in the past",
+			value:  now.Add(-time.Minute).Format(http.TimeFormat),
+			want:   0,
+			wantOk: true,
+		},
+		{
+			name:   "Fail case: empty",
+			value:  "",
+			wantOk: false,
+		},
+		{
+			name:   "Fail case: invalid value",
+			value:  "soon",
+			wantOk: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := parseRetryAfter(tt.value, now)
+			if ok != tt.wantOk || got != tt.want {
+				t.Errorf("parseRetryAfter() = %v, %v, want %v, %v", got, ok, tt.want, tt.wantOk)
+			}
+		})
+	}
+}
+
+func TestIsRetryableError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{
+			name: "Success case: connection refused",
+			err:  fmt.Errorf("fail to execute HTTP request: %w", syscall.ECONNREFUSED),
+			want: true,
+		},
+		{
+			name: "Success case: unexpected EOF",
+			err:  io.ErrUnexpectedEOF,
+			want: true,
+		},
+		{
+			name: "Fail case: context canceled",
+			err:  fmt.Errorf("fail to execute HTTP request: %w", context.Canceled),
+			want: false,
+		},
+		{
+			name: "Fail case: unknown error",
+			err:  errors.New("unknown"),
+			want: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsRetryableError(tt.err); got != tt.want {
+				t.Errorf("IsRetryableError() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,179 @@
+package client
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+var (
+	// Status1xx matches the informational status codes.
+	Status1xx = StatusCodeRange{Min: 100, Max: 200}
+	// Status2xx matches the success status codes.
+	Status2xx = StatusCodeRange{Min: 200, Max: 300}
+	// Status3xx matches the redirection status codes.
+	Status3xx = StatusCodeRange{Min: 300, Max: 400}
+	// Status4xx matches the client error status codes.
+	Status4xx = StatusCodeRange{Min: 400, Max: 500}
+	// Status5xx matches the server error status codes.
+	Status5xx = StatusCodeRange{Min: 500, Max: 600}
+)
+
+// StatusMatcher selects the status codes considered as expected when
+// received from the target API. StatusCodeRange, StatusCodes and the
+// matchers returned by AnyOf, Not and ParseStatusMatcher implement it.
+type StatusMatcher interface {
+	Match(code int) bool
+}
+
+// Match reports if code is within the range.
+func (r StatusCodeRange) Match(code int) bool {
+	return code >= r.Min && code < r.Max
+}
+
+func (r StatusCodeRange) String() string {
+	return fmt.Sprintf("[%d,%d[", r.Min, r.Max)
+}
+
+// StatusCodes is an explicit set of status codes.
+type StatusCodes []int
+
+// Match reports if code is one of the status codes.
+func (s StatusCodes) Match(code int) bool {
+	for _, c := range s {
+		if c == code {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (s StatusCodes) String() string {
+	codes := make([]string, len(s))
+	for i, c := range s {
+		codes[i] = fmt.Sprint(c)
+	}
+
+	return "{" + strings.Join(codes, ",") + "}"
+}
+
+// anyOf matches the status codes matched by one of its matchers.
+type anyOf []StatusMatcher
+
+// AnyOf returns a StatusMatcher matching the status codes matched by one of
+// matchers, such as AnyOf(Status2xx, StatusCodes{404}).
+func AnyOf(matchers ...StatusMatcher) StatusMatcher {
+	return anyOf(matchers)
+}
+
+func (a anyOf) Match(code int) bool {
+	for _, m := range a {
+		if m.Match(code) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (a anyOf) String() string {
+	matchers := make([]string, len(a))
+	for i, m := range a {
+		matchers[i] = fmt.Sprint(m)
+	}
+
+	return strings.Join(matchers, "|")
+}
+
+// not matches the status codes not matched by its matcher.
+type not struct {
+	matcher StatusMatcher
+}
+
+// Not returns a StatusMatcher matching the status codes not matched by
+// matcher, such as Not(StatusCodes{409}).
+func Not(matcher StatusMatcher) StatusMatcher {
+	return not{matcher: matcher}
+}
+
+func (n not) Match(code int) bool {
+	return !n.matcher.Match(code)
+}
+
+func (n not) String() string {
+	return fmt.Sprintf("!%v", n.matcher)
+}
+
+// ParseStatusMatcher parses a comma separated list of status codes and
+// classes, such as "2xx,404". A "!" prefix excludes the following status
+// codes and classes from the previous ones, such as "2xx,3xx,!304".
+func ParseStatusMatcher(s string) (StatusMatcher, error) {
+	var included, excluded anyOf
+	for _, field := range strings.Split(s, ",") {
+		field = strings.TrimSpace(field)
+		exclude := strings.HasPrefix(field, "!")
+		field = strings.TrimPrefix(field, "!")
+
+		matcher, err := parseStatus(field)
+		if err != nil {
+			return nil, fmt.Errorf("can't parse status matcher %q: %w", s, err)
+		}
+		if exclude {
+			excluded = append(excluded, matcher)
+		} else {
+			included = append(included, matcher)
+		}
+	}
+
+	if len(included) == 0 {
+		return nil, fmt.Errorf("can't parse status matcher %q: no status code included", s)
+	}
+
+	var matcher StatusMatcher = included
+	if len(included) == 1 {
+		matcher = included[0]
+	}
+	if len(excluded) > 0 {
+		matcher = allOf{matcher, Not(excluded)}
+	}
+
+	return matcher, nil
+}
+
+// parseStatus parses a status code such as "404" or a class such as "4xx".
+func parseStatus(s string) (StatusMatcher, error) {
+	if len(s) == 3 && strings.EqualFold(s[1:], "xx") && s[0] >= '1' && s[0] <= '5' {
+		min := int(s[0]-'0') * 100
+		return StatusCodeRange{Min: min, Max: min + 100}, nil
+	}
+
+	code, err := strconv.Atoi(s)
+	if err != nil || code < 100 || code > 599 {
+		return nil, fmt.Errorf("invalid status code or class %q", s)
+	}
+
+	return StatusCodes{code}, nil
+}
+
+// allOf matches the status codes matched by all its matchers.
+type allOf []StatusMatcher
+
+func (a allOf) Match(code int) bool {
+	for _, m := range a {
+		if !m.Match(code) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (a allOf) String() string {
+	matchers := make([]string, len(a))
+	for i, m := range a {
+		matchers[i] = fmt.Sprint(m)
+	}
+
+	return strings.Join(matchers, "&")
+}
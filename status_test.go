@@ -0,0 +1,188 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/Aloe-Corporation/client/test"
+)
+
+func TestStatusMatcher_Match(t *testing.T) {
+	tests := []struct {
+		name      string
+		matcher   StatusMatcher
+		matched   []int
+		unmatched []int
+	}{
+		{
+			name:      "Success case: range",
+			matcher:   DefaultStatusRange,
+			matched:   []int{200, 204, 399},
+			unmatched: []int{199, 400, 500},
+		},
+		{
+			name:      "Success case: class",
+			matcher:   Status4xx,
+			matched:   []int{400, 404, 499},
+			unmatched: []int{200, 399, 500},
+		},
+		{
+			name:      "Success case: set",
+			matcher:   StatusCodes{200, 404},
+			matched:   []int{200, 404},
+			unmatched: []int{201, 403, 500},
+		},
+		{
+			name:      "Success case: union",
+			matcher:   AnyOf(Status2xx, StatusCodes{404}),
+			matched:   []int{200, 299, 404},
+			unmatched: []int{301, 403, 500},
+		},
+		{
+			name:      "Success case: negation",
+			matcher:   Not(StatusCodes{409}),
+			matched:   []int{200, 404, 500},
+			unmatched: []int{409},
+		},
+		{
+			name:      "Fail case: empty union",
+			matcher:   AnyOf(),
+			unmatched: []int{200, 404},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, code := range tt.matched {
+				if !tt.matcher.Match(code) {
+					t.Errorf("%v.Match(%d) = false, want true", tt.matcher, code)
+				}
+			}
+			for _, code := range tt.unmatched {
+				if tt.matcher.Match(code) {
+					t.Errorf("%v.Match(%d) = true, want false", tt.matcher, code)
+				}
+			}
+		})
+	}
+}
+
+func TestParseStatusMatcher(t *testing.T) {
+	tests := []struct {
+		name      string
+		s         string
+		matched   []int
+		unmatched []int
+		wantErr   bool
+	}{
+		{
+			name:      "Success case: class",
+			s:         "2xx",
+			matched:   []int{200, 299},
+			unmatched: []int{199, 300},
+		},
+		{
+			name:      "Success case: classes and codes",
+			s:         "2XX, 404",
+			matched:   []int{200, 404},
+			unmatched: []int{403, 500},
+		},
+		{
+			name:      "Success case: exclusion",
+			s:         "2xx,3xx,!304",
+			matched:   []int{200, 301},
+			unmatched: []int{304, 404},
+		},
+		{
+			name:    "Fail case: only exclusions",
+			s:       "!409",
+			wantErr: true,
+		},
+		{
+			name:    "Fail case: invalid class",
+			s:       "6xx",
+			wantErr: true,
+		},
+		{
+			name:    "Fail case: invalid code",
+			s:       "2xx,abc",
+			wantErr: true,
+		},
+		{
+			name:    "Fail case: empty",
+			s:       "",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseStatusMatcher(tt.s)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseStatusMatcher() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			for _, code := range tt.matched {
+				if !got.Match(code) {
+					t.Errorf("%v.Match(%d) = false, want true", got, code)
+				}
+			}
+			for _, code := range tt.unmatched {
+				if got.Match(code) {
+					t.Errorf("%v.Match(%d) = true, want false", got, code)
+				}
+			}
+		})
+	}
+}
+
+func TestConnector_DoWithHeaderCtx_StatusMatcher(t *testing.T) {
+	server := test.DeleteEndpoint()
+	defer server.Close()
+
+	tests := []struct {
+		name    string
+		path    string
+		matcher StatusMatcher
+		want    string
+		wantErr bool
+	}{
+		{
+			name:    "Success case: not found accepted",
+			path:    "/unknown",
+			matcher: AnyOf(Status2xx, StatusCodes{http.StatusNotFound}),
+			want:    "Status not found",
+		},
+		{
+			name:    "Success case: nil matcher",
+			path:    "/delete",
+			matcher: nil,
+			want:    "This is data",
+		},
+		{
+			name:    "Fail case: excluded status code",
+			path:    "/delete",
+			matcher: Not(StatusCodes{http.StatusOK}),
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := factoryConnector(Conf{URL: server.URL, PingEndpoint: "/"}, FactoryHTTPClient())
+
+			got, err := c.DoWithHeaderCtx(context.Background(), http.MethodDelete, tt.path, nil, nil, tt.matcher)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Connector.DoWithHeaderCtx() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				var failErr *FailRequestError
+				if !errors.As(err, &failErr) || failErr.Code != http.StatusOK {
+					t.Errorf("Connector.DoWithHeaderCtx() error = %v, want a 200 *FailRequestError", err)
+				}
+				return
+			}
+			if string(got) != tt.want {
+				t.Errorf("Connector.DoWithHeaderCtx() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
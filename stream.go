@@ -0,0 +1,31 @@
+package client
+
+import (
+	"io"
+	"net/http"
+)
+
+// StreamResponse is a response whose body is handed back unread.
+type StreamResponse struct {
+	StatusCode int           // Status code of the response, matched by the excepted status matcher
+	Header     http.Header   // Headers of the response
+	Body       io.ReadCloser // Live response body, it must be closed by the caller
+}
+
+// DoStream executes req like Connector.DoWithStatusCheck but does not buffer
+// the response body. Once the status code is checked, the live body is
+// returned in the StreamResponse and the caller is responsible for closing it.
+// When the status code is not matched by exceptedStatusCode, a bounded part of the
+// response body is read into the returned *FailRequestError.
+func (c *Connector) DoStream(req *http.Request, exceptedStatusCode StatusMatcher) (*StreamResponse, error) {
+	response, err := c.send(req, exceptedStatusCode)
+	if err != nil {
+		return nil, err
+	}
+
+	return &StreamResponse{
+		StatusCode: response.StatusCode,
+		Header:     response.Header,
+		Body:       response.Body,
+	}, nil
+}
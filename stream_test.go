@@ -0,0 +1,74 @@
+package client
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/Aloe-Corporation/client/test"
+)
+
+func TestConnector_DoStream(t *testing.T) {
+	tests := []struct {
+		name         string
+		size         int
+		status       int
+		wantErr      bool
+		wantBodySize int
+	}{
+		{
+			name:         "Success case",
+			size:         1 << 20,
+			status:       http.StatusOK,
+			wantErr:      false,
+			wantBodySize: 1 << 20,
+		},
+		{
+			name:         "Fail case: bounded error body",
+			size:         1 << 20,
+			status:       http.StatusInternalServerError,
+			wantErr:      true,
+			wantBodySize: defaultMaxErrorBodyBytes,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := test.LargeBodyEndpoint(tt.size, tt.status)
+			defer server.Close()
+			c := &Connector{
+				Client: FactoryHTTPClient(),
+				URL:    server.URL,
+			}
+
+			req, err := http.NewRequest(http.MethodGet, c.URL+"/export", nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := c.DoStream(req, DefaultStatusRange)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Connector.DoStream() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+
+			if tt.wantErr {
+				var failErr *FailRequestError
+				if !errors.As(err, &failErr) || len(failErr.ResponseBody) != tt.wantBodySize {
+					t.Errorf("Connector.DoStream() error = %T, want a *FailRequestError with a %d bytes body", err, tt.wantBodySize)
+				}
+				return
+			}
+			defer got.Body.Close()
+
+			if got.StatusCode != tt.status || got.Header.Get("Content-Type") != "application/octet-stream" {
+				t.Errorf("Connector.DoStream() = %d %v, want %d with headers", got.StatusCode, got.Header, tt.status)
+			}
+
+			n, err := io.Copy(io.Discard, got.Body)
+			if err != nil || n != int64(tt.wantBodySize) {
+				t.Errorf("Connector.DoStream() body read %d bytes, error %v, want %d bytes", n, err, tt.wantBodySize)
+			}
+		})
+	}
+}
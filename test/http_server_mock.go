@@ -1,9 +1,13 @@
 package test
 
 import (
+	"bytes"
 	"fmt"
+	"io"
 	"net/http"
 	"net/http/httptest"
+	"sync/atomic"
+	"time"
 )
 
 // GetPingEndpoint is a HTTP mock endpoint used for testing.
@@ -120,3 +124,325 @@ func DeleteEndpoint() *httptest.Server {
 		}
 	}))
 }
+
+// SlowEndpoint is a HTTP mock endpoint that waits for delay before responding with data.
+// The handler returns early when the incoming request is canceled.
+// Every path is valid.
+func SlowEndpoint(delay time.Duration) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-time.After(delay):
+		case <-r.Context().Done():
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		if _, err := w.Write([]byte("This is data")); err != nil {
+			fmt.Println("can't write in response writer: ", err.Error())
+		}
+	}))
+}
+
+// FlakyEndpoint is a HTTP mock endpoint that responds with the given status code
+// to the first failures requests, then responds with data. When retryAfter is not
+// empty, it is sent as Retry-After header along the failing responses.
+// The returned counter holds the number of received requests.
+// Every path is valid.
+func FlakyEndpoint(failures int, status int, retryAfter string) (*httptest.Server, *atomic.Int32) {
+	counter := &atomic.Int32{}
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if int(counter.Add(1)) <= failures {
+			if retryAfter != "" {
+				w.Header().Set("Retry-After", retryAfter)
+			}
+			w.WriteHeader(status)
+			return
+		}
+
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+		if _, err := w.Write(append([]byte("This is data"), body...)); err != nil {
+			fmt.Println("can't write in response writer: ", err.Error())
+		}
+	})), counter
+}
+
+// JSONEndpoint is a HTTP mock endpoint that responds with JSON data.
+// GET "/json" responds with a JSON object, POST and PUT "/json" echo the request
+// body if its Content-Type is application/json, DELETE "/json" responds without content
+// and GET "/invalid" responds with an invalid JSON body.
+// The server responds with a 406 status code if the request do not accept JSON.
+func JSONEndpoint() *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Accept") != "application/json" {
+			w.WriteHeader(http.StatusNotAcceptable)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case r.URL.Path == "/json" && r.Method == http.MethodGet:
+			if _, err := w.Write([]byte(`{"id":1,"name":"test"}`)); err != nil {
+				fmt.Println("can't write in response writer: ", err.Error())
+			}
+
+		case r.URL.Path == "/json" && (r.Method == http.MethodPost || r.Method == http.MethodPut):
+			if r.Header.Get("Content-Type") != "application/json" {
+				w.WriteHeader(http.StatusUnsupportedMediaType)
+				return
+			}
+			if _, err := io.Copy(w, r.Body); err != nil {
+				fmt.Println("can't write in response writer: ", err.Error())
+			}
+
+		case r.URL.Path == "/json" && r.Method == http.MethodDelete:
+			w.WriteHeader(http.StatusNoContent)
+
+		case r.URL.Path == "/invalid":
+			if _, err := w.Write([]byte(`{"id":`)); err != nil {
+				fmt.Println("can't write in response writer: ", err.Error())
+			}
+
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+}
+
+// LargeBodyEndpoint is a HTTP mock endpoint that responds with the given status code
+// and a body of size bytes, written in chunks of 1 KiB.
+// Every path is valid.
+func LargeBodyEndpoint(size int, status int) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/octet-stream")
+		w.WriteHeader(status)
+
+		chunk := bytes.Repeat([]byte("a"), 1024)
+		for written := 0; written < size; written += len(chunk) {
+			if size-written < len(chunk) {
+				chunk = chunk[:size-written]
+			}
+			if _, err := w.Write(chunk); err != nil {
+				return
+			}
+		}
+	}))
+}
+
+// RedirectEndpoint is a HTTP mock endpoint that redirects "/redirect/{n}" to
+// "/redirect/{n-1}" and "/redirect/0" to target, or to "/get" if target is empty.
+// The "/get" path responds with data.
+func RedirectEndpoint(target string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/get" {
+			if _, err := w.Write([]byte("This is data")); err != nil {
+				fmt.Println("can't write in response writer: ", err.Error())
+			}
+			return
+		}
+
+		var n int
+		if _, err := fmt.Sscanf(r.URL.Path, "/redirect/%d", &n); err != nil {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		location := fmt.Sprintf("/redirect/%d", n-1)
+		if n == 0 {
+			location = "/get"
+			if target != "" {
+				location = target
+			}
+		}
+		http.Redirect(w, r, location, http.StatusFound)
+	}))
+}
+
+// EchoEndpoint is a HTTP mock endpoint that responds with the Authorization header,
+// the X-Api-Key header and the raw query of the request, separated by pipes.
+// Every path is valid.
+func EchoEndpoint() *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		echo := r.Header.Get("Authorization") + "|" + r.Header.Get("X-Api-Key") + "|" + r.URL.RawQuery
+		if _, err := w.Write([]byte(echo)); err != nil {
+			fmt.Println("can't write in response writer: ", err.Error())
+		}
+	}))
+}
+
+// OAuth2TokenEndpoint is a HTTP mock of an OAuth2 token endpoint implementing the
+// client credentials grant for the client "client-id" with the secret "client-secret".
+// It waits for delay, then issues the tokens "token-1", "token-2"... valid for
+// expiresIn seconds. The returned counter holds the number of issued tokens.
+// Every path is valid.
+func OAuth2TokenEndpoint(expiresIn int, delay time.Duration) (*httptest.Server, *atomic.Int32) {
+	counter := &atomic.Int32{}
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(delay)
+
+		id, secret, ok := r.BasicAuth()
+		if !ok {
+			id, secret = r.PostFormValue("client_id"), r.PostFormValue("client_secret")
+		}
+		if r.Method != http.MethodPost || r.PostFormValue("grant_type") != "client_credentials" ||
+			id != "client-id" || secret != "client-secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			if _, err := w.Write([]byte(`{"error":"invalid_client"}`)); err != nil {
+				fmt.Println("can't write in response writer: ", err.Error())
+			}
+			return
+		}
+
+		token := fmt.Sprintf(`{"access_token":"token-%d","token_type":"Bearer","expires_in":%d}`, counter.Add(1), expiresIn)
+		w.Header().Set("Content-Type", "application/json")
+		if _, err := w.Write([]byte(token)); err != nil {
+			fmt.Println("can't write in response writer: ", err.Error())
+		}
+	})), counter
+}
+
+// BearerEndpoint is a HTTP mock endpoint that responds with data to the requests
+// authenticated with the bearer token validToken, and with a 401 status code otherwise.
+// Every path is valid.
+func BearerEndpoint(validToken string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer "+validToken {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if _, err := w.Write([]byte("This is data")); err != nil {
+			fmt.Println("can't write in response writer: ", err.Error())
+		}
+	}))
+}
+
+// CacheEndpoint is a HTTP mock endpoint that responds to GET requests with
+// caching headers:
+// "/max-age" is fresh for a minute, "/etag" must be revalidated with its ETag,
+// "/last-modified" is always stale and must be revalidated with its Last-Modified
+// date, "/no-store" must not be stored and "/vary" varies on Accept-Language.
+// Other methods respond with a 204 status code on every path.
+// The returned counter holds the number of received requests.
+func CacheEndpoint() (*httptest.Server, *atomic.Int32) {
+	counter := &atomic.Int32{}
+	lastModified := time.Date(2020, time.January, 1, 0, 0, 0, 0, time.UTC).Format(http.TimeFormat)
+
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := counter.Add(1)
+		if r.Method != http.MethodGet {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+
+		body := fmt.Sprintf("This is data %d", n)
+		switch r.URL.Path {
+		case "/max-age":
+			w.Header().Set("Cache-Control", "max-age=60")
+
+		case "/etag":
+			w.Header().Set("Cache-Control", "no-cache")
+			w.Header().Set("ETag", `"v1"`)
+			if r.Header.Get("If-None-Match") == `"v1"` {
+				w.WriteHeader(http.StatusNotModified)
+				return
+			}
+
+		case "/last-modified":
+			w.Header().Set("Cache-Control", "max-age=0")
+			w.Header().Set("Last-Modified", lastModified)
+			if r.Header.Get("If-Modified-Since") == lastModified {
+				w.WriteHeader(http.StatusNotModified)
+				return
+			}
+
+		case "/no-store":
+			w.Header().Set("Cache-Control", "no-store, max-age=60")
+
+		case "/vary":
+			w.Header().Set("Cache-Control", "max-age=60")
+			w.Header().Set("Vary", "Accept-Language")
+			body = r.Header.Get("Accept-Language")
+
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		if _, err := w.Write([]byte(body)); err != nil {
+			fmt.Println("can't write in response writer: ", err.Error())
+		}
+	})), counter
+}
+
+// CountingSlowEndpoint is a HTTP mock endpoint that responds with data after
+// delay, or gives up when the request is canceled.
+// The returned counter holds the number of received requests.
+// Every path is valid.
+func CountingSlowEndpoint(delay time.Duration) (*httptest.Server, *atomic.Int32) {
+	counter := &atomic.Int32{}
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		counter.Add(1)
+		select {
+		case <-time.After(delay):
+		case <-r.Context().Done():
+			return
+		}
+		w.Header().Set("Content-Language", r.Header.Get("Accept-Language"))
+		if _, err := w.Write([]byte("This is data")); err != nil {
+			fmt.Println("can't write in response writer: ", err.Error())
+		}
+	})), counter
+}
+
+// SlowFirstEndpoint is a HTTP mock endpoint that responds to the first request
+// after delay, or gives up when it is canceled, and to the next ones right away.
+// The response body holds the number of the request.
+// The returned counter holds the number of received requests.
+// Every path is valid.
+func SlowFirstEndpoint(delay time.Duration) (*httptest.Server, *atomic.Int32) {
+	counter := &atomic.Int32{}
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := counter.Add(1)
+		if n == 1 {
+			select {
+			case <-time.After(delay):
+			case <-r.Context().Done():
+				return
+			}
+		}
+		if _, err := w.Write([]byte(fmt.Sprintf("This is data %d", n))); err != nil {
+			fmt.Println("can't write in response writer: ", err.Error())
+		}
+	})), counter
+}
+
+// ProblemEndpoint is a HTTP mock endpoint that responds with a 404 status
+// code, a RFC 9457 problem details body and a X-Request-Id header.
+// Every path is valid.
+func ProblemEndpoint() *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/problem+json; charset=utf-8")
+		w.Header().Set("X-Request-Id", "req-42")
+		w.WriteHeader(http.StatusNotFound)
+		problem := `{"type":"https://example.com/probs/not-found","title":"Not Found",` +
+			`"status":404,"detail":"user 42 does not exist","instance":"/users/42","user_id":42}`
+		if _, err := w.Write([]byte(problem)); err != nil {
+			fmt.Println("can't write in response writer: ", err.Error())
+		}
+	}))
+}
+
+// PathEndpoint is a HTTP mock endpoint that responds with the escaped path and
+// the raw query of the request, separated by a question mark.
+// Every path is valid.
+func PathEndpoint() *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, err := w.Write([]byte(r.URL.EscapedPath() + "?" + r.URL.RawQuery)); err != nil {
+			fmt.Println("can't write in response writer: ", err.Error())
+		}
+	}))
+}
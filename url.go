@@ -0,0 +1,304 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// PathParams are the values of the placeholders of a path template.
+type PathParams map[string]string
+
+// BuildPath returns the path template with its {name} placeholders replaced
+// by the escaped values of params, followed by the query encoded from query.
+// The placeholders of the path are escaped with url.PathEscape and an empty,
+// "." or ".." value is rejected, so a value can't add or remove segments of
+// the path. The ones of the query part of the template are escaped with
+// url.QueryEscape. query is one of nil, url.Values,
+// map[string]string, map[string][]string or a struct whose fields are
+// tagged like `url:"name,omitempty"`.
+//
+//	BuildPath("/users/{id}/orders", PathParams{"id": "42"}, url.Values{"page": {"2"}})
+//
+// returns "/users/42/orders?page=2".
+func BuildPath(template string, params PathParams, query any) (string, error) {
+	path, rawQuery, hasQuery := strings.Cut(template, "?")
+
+	path, err := expandTemplate(path, params, escapePathSegment)
+	if err != nil {
+		return "", fmt.Errorf("can't build path %q: %w", template, err)
+	}
+	if hasQuery {
+		rawQuery, err = expandTemplate(rawQuery, params, func(value string) (string, error) {
+			return url.QueryEscape(value), nil
+		})
+		if err != nil {
+			return "", fmt.Errorf("can't build path %q: %w", template, err)
+		}
+	}
+
+	values, err := EncodeQuery(query)
+	if err != nil {
+		return "", fmt.Errorf("can't build path %q: %w", template, err)
+	}
+
+	if rawQuery = joinQuery(rawQuery, values.Encode()); rawQuery != "" {
+		path += "?" + rawQuery
+	}
+
+	return path, nil
+}
+
+// BuildURL returns the absolute URL of the path template built by BuildPath,
+// resolved against Connector.URL. It is the URL to use when building the
+// requests given to Connector.DoWithStatusCheck.
+func (c *Connector) BuildURL(template string, params PathParams, query any) (string, error) {
+	path, err := BuildPath(template, params, query)
+	if err != nil {
+		return "", err
+	}
+
+	return resolveURL(c.URL, path)
+}
+
+// newRequest returns a request to path resolved against Connector.URL.
+func (c *Connector) newRequest(ctx context.Context, method, path string, body io.Reader) (*http.Request, error) {
+	target, err := resolveURL(c.URL, path)
+	if err != nil {
+		return nil, fmt.Errorf("can't create the request : %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, target, body)
+	if err != nil {
+		return nil, fmt.Errorf("can't create the request : %w", err)
+	}
+
+	return req, nil
+}
+
+// resolveURL appends path to the path prefix of base, with a single slash
+// between them. The query of path, if any, is appended to the one of base.
+func resolveURL(base, path string) (string, error) {
+	u, err := url.Parse(base)
+	if err != nil {
+		return "", fmt.Errorf("can't parse base URL: %w", err)
+	}
+
+	path, rawQuery, _ := strings.Cut(path, "?")
+	if path != "" {
+		rawPath := strings.TrimRight(u.EscapedPath(), "/") + "/" + strings.TrimLeft(path, "/")
+		if u.Path, err = url.PathUnescape(rawPath); err != nil {
+			return "", fmt.Errorf("can't parse path %q: %w", path, err)
+		}
+		u.RawPath = rawPath
+	}
+	u.RawQuery = joinQuery(u.RawQuery, rawQuery)
+
+	return u.String(), nil
+}
+
+// joinQuery joins the non empty raw queries with "&".
+func joinQuery(queries ...string) string {
+	var parts []string
+	for _, q := range queries {
+		if q != "" {
+			parts = append(parts, q)
+		}
+	}
+
+	return strings.Join(parts, "&")
+}
+
+// escapePathSegment escapes value as a path segment. The empty, "." and ".."
+// values are rejected as they are normalized away by servers and proxies.
+func escapePathSegment(value string) (string, error) {
+	if value == "" || value == "." || value == ".." {
+		return "", fmt.Errorf("invalid path segment %q", value)
+	}
+
+	return url.PathEscape(value), nil
+}
+
+// expandTemplate replaces the {name} placeholders of template by the values
+// of params escaped with escape.
+func expandTemplate(template string, params PathParams, escape func(string) (string, error)) (string, error) {
+	var b strings.Builder
+	for {
+		start := strings.IndexByte(template, '{')
+		if start < 0 {
+			if strings.IndexByte(template, '}') >= 0 {
+				return "", fmt.Errorf("unexpected '}'")
+			}
+			b.WriteString(template)
+			return b.String(), nil
+		}
+
+		end := strings.IndexByte(template[start:], '}')
+		if end < 0 {
+			return "", fmt.Errorf("unterminated placeholder")
+		}
+		end += start
+
+		name := template[start+1 : end]
+		value, ok := params[name]
+		if !ok {
+			return "", fmt.Errorf("missing value of placeholder %q", name)
+		}
+
+		escaped, err := escape(value)
+		if err != nil {
+			return "", fmt.Errorf("can't expand placeholder %q: %w", name, err)
+		}
+
+		b.WriteString(template[:start])
+		b.WriteString(escaped)
+		template = template[end+1:]
+	}
+}
+
+// EncodeQuery returns the query parameters of query, which is one of nil,
+// url.Values, map[string]string, map[string][]string or a struct, or a
+// pointer to it.
+//
+// The fields of a struct are named by their url tag, or by their name when
+// untagged. Fields tagged "-" and unexported fields are skipped, as well as
+// zero fields tagged "omitempty". Strings, booleans, numbers, time.Time
+// (RFC 3339), fmt.Stringer values and slices of them are supported, a slice
+// results in a repeated parameter. Embedded structs are flattened.
+func EncodeQuery(query any) (url.Values, error) {
+	switch q := query.(type) {
+	case nil:
+		return url.Values{}, nil
+	case url.Values:
+		return q, nil
+	case map[string][]string:
+		return url.Values(q), nil
+	case map[string]string:
+		values := make(url.Values, len(q))
+		for k, v := range q {
+			values.Set(k, v)
+		}
+		return values, nil
+	}
+
+	v := reflect.ValueOf(query)
+	for v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			return url.Values{}, nil
+		}
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("can't encode query of type %T", query)
+	}
+
+	values := url.Values{}
+	if err := encodeStruct(values, v); err != nil {
+		return nil, err
+	}
+
+	return values, nil
+}
+
+// encodeStruct adds the fields of the struct v to values.
+func encodeStruct(values url.Values, v reflect.Value) error {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		fv := v.Field(i)
+
+		tag := field.Tag.Get("url")
+		if tag == "-" {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+
+		// The exported fields of an embedded struct are readable even when its
+		// type is unexported, unlike the ones of an embedded struct pointer.
+		if embedded := indirect(fv); field.Anonymous && name == "" && (field.IsExported() || fv.Kind() == reflect.Struct) &&
+			embedded.Kind() == reflect.Struct && embedded.Type() != reflect.TypeOf(time.Time{}) {
+			if err := encodeStruct(values, embedded); err != nil {
+				return err
+			}
+			continue
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		if opts == "omitempty" && fv.IsZero() {
+			continue
+		}
+
+		if fv = indirect(fv); !fv.IsValid() {
+			continue
+		}
+		if fv.Kind() == reflect.Slice || fv.Kind() == reflect.Array {
+			for j := 0; j < fv.Len(); j++ {
+				s, err := formatQueryValue(fv.Index(j))
+				if err != nil {
+					return fmt.Errorf("can't encode query field %s: %w", field.Name, err)
+				}
+				values.Add(name, s)
+			}
+			continue
+		}
+
+		s, err := formatQueryValue(fv)
+		if err != nil {
+			return fmt.Errorf("can't encode query field %s: %w", field.Name, err)
+		}
+		values.Add(name, s)
+	}
+
+	return nil
+}
+
+// indirect dereferences the pointers of v, it returns the zero Value for a nil pointer.
+func indirect(v reflect.Value) reflect.Value {
+	for v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			return reflect.Value{}
+		}
+		v = v.Elem()
+	}
+
+	return v
+}
+
+// formatQueryValue returns the query parameter value of v.
+func formatQueryValue(v reflect.Value) (string, error) {
+	if v = indirect(v); !v.IsValid() {
+		return "", nil
+	}
+
+	switch x := v.Interface().(type) {
+	case time.Time:
+		return x.Format(time.RFC3339), nil
+	case fmt.Stringer:
+		return x.String(), nil
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		return v.String(), nil
+	case reflect.Bool:
+		return strconv.FormatBool(v.Bool()), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(v.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(v.Uint(), 10), nil
+	case reflect.Float32, reflect.Float64:
+		return strconv.FormatFloat(v.Float(), 'f', -1, v.Type().Bits()), nil
+	}
+
+	return "", fmt.Errorf("unsupported type %s", v.Type())
+}
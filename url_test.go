@@ -0,0 +1,306 @@
+package client
+
+import (
+	"net/url"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/Aloe-Corporation/client/test"
+)
+
+type pageQuery struct {
+	Page  int `url:"page"`
+	Limit int `url:"limit,omitempty"`
+}
+
+type ordersQuery struct {
+	pageQuery
+	Status   []string   `url:"status"`
+	Since    time.Time  `url:"since,omitempty"`
+	Timeout  *int       `url:"timeout"`
+	Expand   bool       `url:"expand,omitempty"`
+	Internal string     `url:"-"`
+	Sort     string     `url:"sort,omitempty"`
+	Ratio    float64    `url:"ratio,omitempty"`
+	Interval *time.Time `url:"interval,omitempty"`
+	internal string
+}
+
+type PublicPage struct {
+	Page int `url:"page"`
+}
+
+type publicQuery struct {
+	*PublicPage
+	Name string
+}
+
+func TestBuildPath(t *testing.T) {
+	tests := []struct {
+		name     string
+		template string
+		params   PathParams
+		query    any
+		want     string
+		wantErr  bool
+	}{
+		{
+			name:     "Success case: placeholders",
+			template: "/users/{id}/orders/{order}",
+			params:   PathParams{"id": "42", "order": "7"},
+			want:     "/users/42/orders/7",
+		},
+		{
+			name:     "Success case: escaped segment",
+			template: "/users/{id}",
+			params:   PathParams{"id": "../admin?x=1#y"},
+			want:     "/users/..%2Fadmin%3Fx=1%23y",
+		},
+		{
+			name:     "Success case: template query",
+			template: "/search?q={q}",
+			params:   PathParams{"q": "a&b c"},
+			query:    url.Values{"page": {"2"}},
+			want:     "/search?q=a%26b+c&page=2",
+		},
+		{
+			name:     "Success case: map query",
+			template: "/users",
+			query:    map[string]string{"b": "2", "a": "1"},
+			want:     "/users?a=1&b=2",
+		},
+		{
+			name:     "Success case: empty query value",
+			template: "/search?q={q}",
+			params:   PathParams{"q": ""},
+			want:     "/search?q=",
+		},
+		{
+			name:     "Fail case: missing placeholder value",
+			template: "/users/{id}",
+			wantErr:  true,
+		},
+		{
+			name:     "Fail case: unterminated placeholder",
+			template: "/users/{id",
+			params:   PathParams{"id": "42"},
+			wantErr:  true,
+		},
+		{
+			name:     "Fail case: unexpected brace",
+			template: "/users/id}",
+			wantErr:  true,
+		},
+		{
+			name:     "Fail case: empty segment",
+			template: "/users/{id}/orders",
+			params:   PathParams{"id": ""},
+			wantErr:  true,
+		},
+		{
+			name:     "Fail case: dot segment",
+			template: "/users/{id}/orders",
+			params:   PathParams{"id": "."},
+			wantErr:  true,
+		},
+		{
+			name:     "Fail case: dot-dot segment",
+			template: "/users/{id}/orders",
+			params:   PathParams{"id": ".."},
+			wantErr:  true,
+		},
+		{
+			name:     "Fail case: invalid query",
+			template: "/users",
+			query:    42,
+			wantErr:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := BuildPath(tt.template, tt.params, tt.query)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("BuildPath() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("BuildPath() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEncodeQuery(t *testing.T) {
+	since := time.Date(2024, time.March, 1, 12, 0, 0, 0, time.UTC)
+	timeout := 30
+
+	tests := []struct {
+		name    string
+		query   any
+		want    url.Values
+		wantErr bool
+	}{
+		{
+			name:  "Success case: nil",
+			query: nil,
+			want:  url.Values{},
+		},
+		{
+			name: "Success case: tagged struct",
+			query: &ordersQuery{
+				pageQuery: pageQuery{Page: 2},
+				Status:    []string{"paid", "sent"},
+				Since:     since,
+				Timeout:   &timeout,
+				Internal:  "secret",
+				Ratio:     0.5,
+				internal:  "secret",
+			},
+			want: url.Values{
+				"page":    {"2"},
+				"status":  {"paid", "sent"},
+				"since":   {"2024-03-01T12:00:00Z"},
+				"timeout": {"30"},
+				"ratio":   {"0.5"},
+			},
+		},
+		{
+			name:  "Success case: untagged fields and nil embedded struct",
+			query: publicQuery{Name: "bob"},
+			want:  url.Values{"Name": {"bob"}},
+		},
+		{
+			name:  "Success case: embedded struct pointer",
+			query: publicQuery{PublicPage: &PublicPage{Page: 3}},
+			want:  url.Values{"page": {"3"}, "Name": {""}},
+		},
+		{
+			name:  "Success case: nil pointer",
+			query: (*ordersQuery)(nil),
+			want:  url.Values{},
+		},
+		{
+			name:    "Fail case: unsupported field",
+			query:   struct{ Filter map[string]string }{Filter: map[string]string{"a": "b"}},
+			wantErr: true,
+		},
+		{
+			name:    "Fail case: unsupported type",
+			query:   []string{"a"},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := EncodeQuery(tt.query)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("EncodeQuery() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("EncodeQuery() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestResolveURL(t *testing.T) {
+	tests := []struct {
+		name    string
+		base    string
+		path    string
+		want    string
+		wantErr bool
+	}{
+		{
+			name: "Success case: base without path",
+			base: "https://myserver.com",
+			path: "/users",
+			want: "https://myserver.com/users",
+		},
+		{
+			name: "Success case: base path prefix",
+			base: "https://myserver.com/api/v1",
+			path: "/users",
+			want: "https://myserver.com/api/v1/users",
+		},
+		{
+			name: "Success case: double slashes",
+			base: "https://myserver.com/api/",
+			path: "//users",
+			want: "https://myserver.com/api/users",
+		},
+		{
+			name: "Success case: path without leading slash",
+			base: "https://myserver.com/api",
+			path: "users",
+			want: "https://myserver.com/api/users",
+		},
+		{
+			name: "Success case: escaped segment kept",
+			base: "https://myserver.com/api",
+			path: "/users/a%2Fb",
+			want: "https://myserver.com/api/users/a%2Fb",
+		},
+		{
+			name: "Success case: queries joined",
+			base: "https://myserver.com/api?key=1",
+			path: "/users?page=2",
+			want: "https://myserver.com/api/users?key=1&page=2",
+		},
+		{
+			name: "Success case: query only",
+			base: "https://myserver.com/api",
+			path: "?page=2",
+			want: "https://myserver.com/api?page=2",
+		},
+		{
+			name: "Success case: empty path",
+			base: "https://myserver.com/api",
+			want: "https://myserver.com/api",
+		},
+		{
+			name:    "Fail case: invalid escape",
+			base:    "https://myserver.com",
+			path:    "/users/%zz",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := resolveURL(tt.base, tt.path)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("resolveURL() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("resolveURL() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConnector_BuildURL(t *testing.T) {
+	server := test.PathEndpoint()
+	defer server.Close()
+
+	c := factoryConnector(Conf{URL: server.URL + "/api/", PingEndpoint: "/"}, FactoryHTTPClient())
+
+	target, err := c.BuildURL("/users/{id}/orders", PathParams{"id": "a/b"}, pageQuery{Page: 2})
+	if err != nil {
+		t.Fatalf("Connector.BuildURL() error = %v", err)
+	}
+	if want := server.URL + "/api/users/a%2Fb/orders?page=2"; target != want {
+		t.Errorf("Connector.BuildURL() = %q, want %q", target, want)
+	}
+
+	path, err := BuildPath("/users/{id}/orders", PathParams{"id": "a/b"}, pageQuery{Page: 2})
+	if err != nil {
+		t.Fatalf("BuildPath() error = %v", err)
+	}
+	got, err := c.SimpleGet(path)
+	if err != nil {
+		t.Fatalf("Connector.SimpleGet() error = %v", err)
+	}
+	if want := "/api/users/a%2Fb/orders?page=2"; string(got) != want {
+		t.Errorf("Connector.SimpleGet() = %q, want %q", got, want)
+	}
+}